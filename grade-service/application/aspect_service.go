@@ -0,0 +1,150 @@
+package application
+
+import (
+	"errors"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/mmmajder/zms-devops-grade-service/application/text"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"github.com/mmmajder/zms-devops-grade-service/util"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/trace"
+	"sort"
+	"time"
+)
+
+const (
+	minAspectMentions   = 2
+	maxStoredTerms      = 50
+	maxAspects          = 5
+	positiveAspectGrade = 4
+	negativeAspectGrade = 2.5
+)
+
+type AspectService struct {
+	store        domain.ReviewStore
+	summaryStore domain.ReviewSummaryStore
+}
+
+func NewAspectService(store domain.ReviewStore, summaryStore domain.ReviewSummaryStore) *AspectService {
+	return &AspectService{
+		store:        store,
+		summaryStore: summaryStore,
+	}
+}
+
+// SummarizeAll rebuilds the term summary of every reviewed subject and of
+// every subject that still has a summary, so summaries of subjects whose
+// reviews were all deleted or hidden are removed. It runs as a background
+// job, so a failing subject is logged and skipped.
+func (service *AspectService) SummarizeAll(span trace.Span, loki promtail.Client) error {
+	util.HttpTraceInfo("Fetching reviewed subjects...", span, loki, "SummarizeAll", "")
+	subjects, err := service.store.GetSubjects()
+	if err != nil {
+		return err
+	}
+	summarized, err := service.summaryStore.GetSubjects()
+	if err != nil {
+		return err
+	}
+	seen := make(map[domain.ReviewSubject]struct{}, len(subjects))
+	for _, subject := range subjects {
+		seen[subject] = struct{}{}
+	}
+	for _, subject := range summarized {
+		if _, ok := seen[subject]; !ok {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	for _, subject := range subjects {
+		if err := service.Summarize(subject.SubReviewed, subject.Type, span, loki); err != nil {
+			util.HttpTraceError(err, "failed to summarize "+subject.SubReviewed, span, loki, "SummarizeAll", "")
+		}
+	}
+	return nil
+}
+
+// Summarize rebuilds the term summary of a subject from its published
+// reviews, and removes the summary once the subject has none.
+func (service *AspectService) Summarize(subReviewed string, reviewType domain.ReviewType, span trace.Span, loki promtail.Client) error {
+	reviews, err := service.store.GetAllBySubReviewed(subReviewed, reviewType)
+	if err != nil {
+		return err
+	}
+	if len(reviews) == 0 {
+		util.HttpTraceInfo("Removing summary of "+subReviewed+"...", span, loki, "Summarize", "")
+		return service.summaryStore.Delete(subReviewed, reviewType)
+	}
+
+	terms := countTerms(reviews)
+	summary := &domain.ReviewSummary{
+		SubReviewed:        subReviewed,
+		Type:               reviewType,
+		Terms:              limitTerms(terms, maxStoredTerms),
+		TopPositive:        limitTerms(selectTerms(terms, func(stats domain.TermStats) bool { return stats.AverageGrade >= positiveAspectGrade }), maxAspects),
+		TopNegative:        limitTerms(selectTerms(terms, func(stats domain.TermStats) bool { return stats.AverageGrade <= negativeAspectGrade }), maxAspects),
+		DateOfModification: time.Now(),
+	}
+	return service.summaryStore.Upsert(summary)
+}
+
+func (service *AspectService) GetAspects(subReviewed string, reviewType domain.ReviewType) ([]dto.AspectDTO, []dto.AspectDTO, error) {
+	summary, err := service.summaryStore.Get(subReviewed, reviewType)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return []dto.AspectDTO{}, []dto.AspectDTO{}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return dto.FromTermStats(summary.TopPositive), dto.FromTermStats(summary.TopNegative), nil
+}
+
+// countTerms returns the terms mentioned by at least minAspectMentions
+// reviews, most mentioned first, with the average grade of those reviews.
+func countTerms(reviews []*domain.Review) []domain.TermStats {
+	mentions := make(map[string]int)
+	grades := make(map[string]float32)
+	for _, review := range reviews {
+		for _, term := range text.Terms(review.Comment) {
+			mentions[term]++
+			grades[term] += review.Grade
+		}
+	}
+
+	terms := make([]domain.TermStats, 0, len(mentions))
+	for term, count := range mentions {
+		if count < minAspectMentions {
+			continue
+		}
+		terms = append(terms, domain.TermStats{
+			Term:         term,
+			Mentions:     count,
+			AverageGrade: grades[term] / float32(count),
+		})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Mentions != terms[j].Mentions {
+			return terms[i].Mentions > terms[j].Mentions
+		}
+		return terms[i].Term < terms[j].Term
+	})
+	return terms
+}
+
+func selectTerms(terms []domain.TermStats, keep func(domain.TermStats) bool) []domain.TermStats {
+	selected := make([]domain.TermStats, 0)
+	for _, stats := range terms {
+		if keep(stats) {
+			selected = append(selected, stats)
+		}
+	}
+	return selected
+}
+
+func limitTerms(terms []domain.TermStats, limit int) []domain.TermStats {
+	if len(terms) > limit {
+		return terms[:limit]
+	}
+	return terms
+}
@@ -0,0 +1,171 @@
+package application
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"github.com/mmmajder/zms-devops-grade-service/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type AttachmentLimits struct {
+	MaxSize             int64
+	MaxCount            int
+	AllowedContentTypes []string
+}
+
+type AttachmentService struct {
+	store      domain.ReviewStore
+	blobStore  domain.BlobStore
+	limits     AttachmentLimits
+	signingKey []byte
+	urlTTL     time.Duration
+}
+
+func NewAttachmentService(store domain.ReviewStore, blobStore domain.BlobStore, limits AttachmentLimits, signingKey []byte, urlTTL time.Duration) *AttachmentService {
+	return &AttachmentService{
+		store:      store,
+		blobStore:  blobStore,
+		limits:     limits,
+		signingKey: signingKey,
+		urlTTL:     urlTTL,
+	}
+}
+
+func (service *AttachmentService) Limits() AttachmentLimits {
+	return service.limits
+}
+
+func (service *AttachmentService) Upload(reviewId primitive.ObjectID, uploaderSub string, fileName string, content io.Reader, span trace.Span, loki promtail.Client) (dto.AttachmentDTO, error) {
+	util.HttpTraceInfo("Fetching review by id...", span, loki, "Upload", "")
+	review, err := service.store.Get(reviewId)
+	if err != nil {
+		return dto.AttachmentDTO{}, err
+	}
+	if review.SubReviewer != uploaderSub {
+		return dto.AttachmentDTO{}, domain.ErrNotReviewAuthor
+	}
+	if len(review.Attachments) >= service.limits.MaxCount {
+		return dto.AttachmentDTO{}, domain.ErrTooManyAttachments
+	}
+
+	data, err := io.ReadAll(io.LimitReader(content, service.limits.MaxSize+1))
+	if err != nil {
+		return dto.AttachmentDTO{}, err
+	}
+	if int64(len(data)) > service.limits.MaxSize {
+		return dto.AttachmentDTO{}, domain.ErrAttachmentTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if !service.isAllowed(contentType) {
+		return dto.AttachmentDTO{}, domain.ErrUnsupportedAttachmentType
+	}
+
+	attachment := domain.Attachment{
+		Id:             primitive.NewObjectID().Hex(),
+		FileName:       fileName,
+		ContentType:    contentType,
+		Size:           int64(len(data)),
+		DateOfCreation: time.Now(),
+	}
+	util.HttpTraceInfo("Storing attachment content...", span, loki, "Upload", "")
+	if err := service.blobStore.Put(attachment.Id, contentType, bytes.NewReader(data)); err != nil {
+		return dto.AttachmentDTO{}, err
+	}
+
+	util.HttpTraceInfo("Adding attachment to review...", span, loki, "Upload", "")
+	if _, err := service.store.AddAttachment(reviewId, attachment, service.limits.MaxCount); err != nil {
+		_ = service.blobStore.Delete(attachment.Id)
+		return dto.AttachmentDTO{}, err
+	}
+
+	return service.toDTO(attachment), nil
+}
+
+// Open returns the content of an attachment once the signature of its
+// served URL has been verified.
+func (service *AttachmentService) Open(attachmentId string, expires string, signature string, span trace.Span, loki promtail.Client) (io.ReadCloser, domain.Attachment, error) {
+	if !service.verify(attachmentId, expires, signature) {
+		return nil, domain.Attachment{}, domain.ErrInvalidAttachmentSignature
+	}
+
+	util.HttpTraceInfo("Fetching review by attachment...", span, loki, "Open", "")
+	review, err := service.store.GetByAttachment(attachmentId)
+	if err != nil {
+		return nil, domain.Attachment{}, err
+	}
+
+	for _, attachment := range review.Attachments {
+		if attachment.Id == attachmentId {
+			content, err := service.blobStore.Get(attachmentId)
+			return content, attachment, err
+		}
+	}
+	return nil, domain.Attachment{}, domain.ErrInvalidAttachmentSignature
+}
+
+func (service *AttachmentService) DeleteAll(review *domain.Review, span trace.Span, loki promtail.Client) {
+	for _, attachment := range review.Attachments {
+		if err := service.blobStore.Delete(attachment.Id); err != nil {
+			util.HttpTraceError(err, "failed to delete attachment "+attachment.Id, span, loki, "DeleteAll", "")
+		}
+	}
+}
+
+// WithUrls fills in signed URLs for the attachments of the given reviews.
+func (service *AttachmentService) WithUrls(reviews []dto.ReviewDTO) []dto.ReviewDTO {
+	for i := range reviews {
+		for j := range reviews[i].Attachments {
+			reviews[i].Attachments[j].Url = service.signedUrl(reviews[i].Attachments[j].Id)
+		}
+	}
+	return reviews
+}
+
+func (service *AttachmentService) toDTO(attachment domain.Attachment) dto.AttachmentDTO {
+	attachmentDTO := dto.FromAttachment(attachment)
+	attachmentDTO.Url = service.signedUrl(attachment.Id)
+	return attachmentDTO
+}
+
+func (service *AttachmentService) signedUrl(attachmentId string) string {
+	expires := strconv.FormatInt(time.Now().Add(service.urlTTL).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", service.sign(attachmentId, expires))
+	return domain.GradeContextPath + "/attachments/" + attachmentId + "?" + query.Encode()
+}
+
+func (service *AttachmentService) sign(attachmentId string, expires string) string {
+	mac := hmac.New(sha256.New, service.signingKey)
+	mac.Write([]byte(attachmentId + ":" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (service *AttachmentService) verify(attachmentId string, expires string, signature string) bool {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return false
+	}
+	return hmac.Equal([]byte(service.sign(attachmentId, expires)), []byte(signature))
+}
+
+func (service *AttachmentService) isAllowed(contentType string) bool {
+	for _, allowed := range service.limits.AllowedContentTypes {
+		if allowed == contentType {
+			return true
+		}
+	}
+	return false
+}
@@ -2,28 +2,76 @@ package external
 
 import (
 	"context"
+	"fmt"
 	booking "github.com/ZMS-DevOps/booking-service/proto"
 	"github.com/afiskon/promtail-client/promtail"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/metrics"
 	"github.com/mmmajder/zms-devops-grade-service/util"
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"log"
+	"strings"
+	"time"
 )
 
-func NewBookingClient(address string) booking.BookingServiceClient {
+// BookingServiceClient is the part of the booking service the grade service
+// calls. It is an interface of its own so tests can stand in for booking.
+type BookingServiceClient interface {
+	booking.BookingServiceClient
+	CheckHostHasReservationForGuest(ctx context.Context, in *CheckHostHasReservationForGuestRequest, opts ...grpc.CallOption) (*CheckHostHasReservationForGuestResponse, error)
+}
+
+type BookingClient struct {
+	booking.BookingServiceClient
+	conn *grpc.ClientConn
+}
+
+func NewBookingClient(address string) *BookingClient {
 	conn, err := getConnection(address)
 	if err != nil {
 		log.Fatalf("Failed to start gRPC connection to Catalogue service: %v", err)
 	}
-	return booking.NewBookingServiceClient(conn)
+	return &BookingClient{
+		BookingServiceClient: booking.NewBookingServiceClient(conn),
+		conn:                 conn,
+	}
+}
+
+// CheckConnectivity waits until the connection to the booking service is
+// ready, dialing it if it is idle, or until the context is done.
+func (client *BookingClient) CheckConnectivity(ctx context.Context) error {
+	for {
+		state := client.conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+		if state == connectivity.Idle {
+			client.conn.Connect()
+		}
+		if !client.conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("booking connection is %s", strings.ToLower(state.String()))
+		}
+	}
 }
 
 func getConnection(address string) (*grpc.ClientConn, error) {
-	return grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	return grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithUnaryInterceptor(observeCall))
 }
 
-func IfHostCanBeDeleted(bookingClient booking.BookingServiceClient, id string, span trace.Span, loki promtail.Client) (*booking.CheckDeleteHostResponse, error) {
+func observeCall(ctx context.Context, method string, req, reply interface{}, conn *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, conn, opts...)
+	metrics.BookingRequestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.BookingRequestErrors.WithLabelValues(method, status.Code(err).String()).Inc()
+	}
+	return err
+}
+
+func IfHostCanBeDeleted(bookingClient BookingServiceClient, id string, span trace.Span, loki promtail.Client) (*booking.CheckDeleteHostResponse, error) {
 	util.HttpTraceInfo("Checking if host can be deleted...", span, loki, "IfHostCanBeDeleted", "")
 	return bookingClient.CheckDeleteHost(
 		context.TODO(),
@@ -32,7 +80,7 @@ func IfHostCanBeDeleted(bookingClient booking.BookingServiceClient, id string, s
 		})
 }
 
-func IfGuestCanBeDeleted(bookingClient booking.BookingServiceClient, id string, span trace.Span, loki promtail.Client) (*booking.CheckDeleteClientResponse, error) {
+func IfGuestCanBeDeleted(bookingClient BookingServiceClient, id string, span trace.Span, loki promtail.Client) (*booking.CheckDeleteClientResponse, error) {
 	util.HttpTraceInfo("Checking if guest can be deleted...", span, loki, "IfGuestCanBeDeleted", "")
 	return bookingClient.CheckDeleteClient(
 		context.TODO(),
@@ -41,7 +89,7 @@ func IfGuestCanBeDeleted(bookingClient booking.BookingServiceClient, id string,
 		})
 }
 
-func IfGuestCanReviewHost(bookingClient booking.BookingServiceClient, reviewerSub string, reviewedSub string, span trace.Span, loki promtail.Client) (*booking.CheckGuestHasReservationForHostResponse, error) {
+func IfGuestCanReviewHost(bookingClient BookingServiceClient, reviewerSub string, reviewedSub string, span trace.Span, loki promtail.Client) (*booking.CheckGuestHasReservationForHostResponse, error) {
 	util.HttpTraceInfo("Checking if guest can review host...", span, loki, "IfGuestCanReviewHost", "")
 	return bookingClient.CheckGuestHasReservationForHost(
 		context.TODO(),
@@ -51,7 +99,7 @@ func IfGuestCanReviewHost(bookingClient booking.BookingServiceClient, reviewerSu
 		})
 }
 
-func IfGuestCanReviewAccommodation(bookingClient booking.BookingServiceClient, reviewerSub string, reviewedSub string, span trace.Span, loki promtail.Client) (*booking.CheckGuestHasReservationForAccommodationResponse, error) {
+func IfGuestCanReviewAccommodation(bookingClient BookingServiceClient, reviewerSub string, reviewedSub string, span trace.Span, loki promtail.Client) (*booking.CheckGuestHasReservationForAccommodationResponse, error) {
 	util.HttpTraceInfo("Checking if guest can review accommodation...", span, loki, "IfGuestCanReviewAccommodation", "")
 	return bookingClient.CheckGuestHasReservationForAccommodation(
 		context.TODO(),
@@ -60,3 +108,13 @@ func IfGuestCanReviewAccommodation(bookingClient booking.BookingServiceClient, r
 			AccommodationId: reviewedSub,
 		})
 }
+
+func IfHostCanReviewGuest(bookingClient BookingServiceClient, reviewerSub string, reviewedSub string, span trace.Span, loki promtail.Client) (*CheckHostHasReservationForGuestResponse, error) {
+	util.HttpTraceInfo("Checking if host can review guest...", span, loki, "IfHostCanReviewGuest", "")
+	return bookingClient.CheckHostHasReservationForGuest(
+		context.TODO(),
+		&CheckHostHasReservationForGuestRequest{
+			HostId:  reviewerSub,
+			GuestId: reviewedSub,
+		})
+}
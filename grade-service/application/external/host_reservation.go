@@ -0,0 +1,92 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// checkHostHasReservationForGuestMethod is the booking RPC that tells whether a
+// guest stayed with a host, asked from the host's side:
+//
+//	rpc CheckHostHasReservationForGuest(CheckHostHasReservationForGuestRequest) returns (CheckHostHasReservationForGuestResponse);
+//	message CheckHostHasReservationForGuestRequest { string hostId = 1; string guestId = 2; }
+//	message CheckHostHasReservationForGuestResponse { bool hasReservation = 1; }
+//
+// The published booking proto (v1.0.12) does not define it yet, so the client
+// encodes the messages itself; booking must serve the RPC before hosts can
+// review guests.
+const checkHostHasReservationForGuestMethod = "/booking.BookingService/CheckHostHasReservationForGuest"
+
+type CheckHostHasReservationForGuestRequest struct {
+	HostId  string
+	GuestId string
+}
+
+type CheckHostHasReservationForGuestResponse struct {
+	HasReservation bool
+}
+
+func (client *BookingClient) CheckHostHasReservationForGuest(ctx context.Context, in *CheckHostHasReservationForGuestRequest, opts ...grpc.CallOption) (*CheckHostHasReservationForGuestResponse, error) {
+	out := new(CheckHostHasReservationForGuestResponse)
+	opts = append(opts, grpc.ForceCodec(hostReservationCodec{}))
+	if err := client.conn.Invoke(ctx, checkHostHasReservationForGuestMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// hostReservationCodec writes and reads the host reservation messages in the
+// protobuf wire format, as generated code would.
+type hostReservationCodec struct{}
+
+func (hostReservationCodec) Name() string {
+	return "proto"
+}
+
+func (hostReservationCodec) Marshal(v interface{}) ([]byte, error) {
+	request, ok := v.(*CheckHostHasReservationForGuestRequest)
+	if !ok {
+		return nil, fmt.Errorf("can not marshal %T", v)
+	}
+	var b []byte
+	if request.HostId != "" {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, request.HostId)
+	}
+	if request.GuestId != "" {
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendString(b, request.GuestId)
+	}
+	return b, nil
+}
+
+func (hostReservationCodec) Unmarshal(data []byte, v interface{}) error {
+	response, ok := v.(*CheckHostHasReservationForGuestResponse)
+	if !ok {
+		return fmt.Errorf("can not unmarshal into %T", v)
+	}
+	for len(data) > 0 {
+		number, wireType, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if number == 1 && wireType == protowire.VarintType {
+			value, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			response.HasReservation = protowire.DecodeBool(value)
+			data = data[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(number, wireType, data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package application
+
+import (
+	"fmt"
+	"github.com/mmmajder/zms-devops-grade-service/application/text"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// minDuplicateShingles keeps one- or two-word comments such as "Great stay"
+	// out of the near-duplicate check; those are identical by nature.
+	minDuplicateShingles = 3
+	// minConsensusReviews is how many reviews by others a subject needs before
+	// a reviewer can be said to disagree with them.
+	minConsensusReviews = 3
+	// minExtremeDeviation is how many stars an extreme reviewer must be away
+	// from everyone else; a five star review of a well liked host is normal.
+	minExtremeDeviation = 2
+	maxGradeDeviation   = 4
+)
+
+type FraudDetectionSettings struct {
+	BurstWindow           time.Duration
+	BurstThreshold        int
+	ExtremeMinReviews     int
+	DuplicateSimilarity   float64
+	FreshAccountAge       time.Duration
+	FreshAccountThreshold int
+	// HideScore is the suspicion score from which the involved reviews are
+	// hidden pending moderation, when AutoHide is on.
+	HideScore float64
+	// AutoHide lets high scoring findings hide reviews. It stays off until the
+	// rules are calibrated on real traffic; findings are recorded and their
+	// reviews flagged for moderators either way.
+	AutoHide bool
+}
+
+// FraudDetector looks for coordinated rating manipulation among the reviews
+// of one subject. Reviews are dated by their ObjectID, which records when
+// they were created; DateOfModification changes on edits.
+type FraudDetector struct {
+	store          domain.ReviewStore
+	suspicionStore domain.SuspicionStore
+	settings       FraudDetectionSettings
+}
+
+func NewFraudDetector(store domain.ReviewStore, suspicionStore domain.SuspicionStore, settings FraudDetectionSettings) *FraudDetector {
+	return &FraudDetector{
+		store:          store,
+		suspicionStore: suspicionStore,
+		settings:       settings,
+	}
+}
+
+// Inspect runs every check on the subject, stores the findings and returns
+// them.
+func (detector *FraudDetector) Inspect(subReviewed string, reviewType domain.ReviewType) ([]*domain.Suspicion, error) {
+	reviews, err := detector.store.GetAllBySubReviewed(subReviewed, reviewType)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(reviews, func(i, j int) bool {
+		return createdAt(reviews[i]).Before(createdAt(reviews[j]))
+	})
+
+	histories, err := detector.getReviewerHistories(reviews)
+	if err != nil {
+		return nil, err
+	}
+
+	var suspicions []*domain.Suspicion
+	suspicions = append(suspicions, detector.detectBurst(subReviewed, reviewType, reviews)...)
+	suspicions = append(suspicions, detector.detectExtremeReviewers(subReviewed, reviewType, reviews, histories)...)
+	suspicions = append(suspicions, detector.detectNearDuplicates(subReviewed, reviewType, reviews)...)
+	suspicions = append(suspicions, detector.detectFreshAccounts(subReviewed, reviewType, reviews)...)
+
+	now := time.Now()
+	for _, suspicion := range suspicions {
+		suspicion.DateOfCreation = now
+		suspicion.LastDetectedAt = now
+		if err := detector.suspicionStore.Upsert(suspicion); err != nil {
+			return nil, err
+		}
+	}
+	return suspicions, nil
+}
+
+func (detector *FraudDetector) ShouldHide(suspicion *domain.Suspicion) bool {
+	return detector.settings.AutoHide && suspicion.Score >= detector.settings.HideScore
+}
+
+func (detector *FraudDetector) GetSuspicions() ([]*domain.Suspicion, error) {
+	return detector.suspicionStore.GetAll()
+}
+
+// getReviewerHistories reads the reviews of every reviewer of the subject in
+// one query.
+func (detector *FraudDetector) getReviewerHistories(reviews []*domain.Review) (map[string][]*domain.Review, error) {
+	reviewers := make([]string, 0, len(reviews))
+	histories := make(map[string][]*domain.Review)
+	for _, review := range reviews {
+		if _, ok := histories[review.SubReviewer]; ok {
+			continue
+		}
+		histories[review.SubReviewer] = nil
+		reviewers = append(reviewers, review.SubReviewer)
+	}
+
+	written, err := detector.store.GetAllBySubReviewers(reviewers)
+	if err != nil {
+		return nil, err
+	}
+	for _, review := range written {
+		histories[review.SubReviewer] = append(histories[review.SubReviewer], review)
+	}
+	return histories, nil
+}
+
+// detectBurst finds the busiest BurstWindow of the subject and compares it
+// with the usual rate of the subject outside that window, so popular subjects
+// are not flagged for their ordinary traffic. The score is 1 - 1/ratio: a
+// window ten times busier than usual scores 0.9.
+func (detector *FraudDetector) detectBurst(subReviewed string, reviewType domain.ReviewType, reviews []*domain.Review) []*domain.Suspicion {
+	window := detector.settings.BurstWindow
+	bestStart, bestEnd := 0, 0
+	start := 0
+	for end := range reviews {
+		for createdAt(reviews[end]).Sub(createdAt(reviews[start])) > window {
+			start++
+		}
+		if end-start > bestEnd-bestStart {
+			bestStart, bestEnd = start, end
+		}
+	}
+
+	count := bestEnd - bestStart + 1
+	if len(reviews) == 0 || count < detector.settings.BurstThreshold {
+		return nil
+	}
+	history := createdAt(reviews[len(reviews)-1]).Sub(createdAt(reviews[0])) - window
+	windows := max(1, float64(history)/float64(window))
+	usual := max(1, float64(len(reviews)-count)/windows)
+	ratio := float64(count) / usual
+
+	burst := reviews[bestStart : bestEnd+1]
+	return []*domain.Suspicion{{
+		Key:         subjectKey(domain.SuspicionReviewBurst, subReviewed, reviewType),
+		Kind:        domain.SuspicionReviewBurst,
+		SubReviewed: subReviewed,
+		Type:        reviewType,
+		ReviewIds:   reviewIds(burst),
+		Score:       1 - 1/ratio,
+		Details:     fmt.Sprintf("%d reviews within %s, usually %.1f", count, window, usual),
+	}}
+}
+
+// detectExtremeReviewers flags reviewers of the subject who only ever give
+// one or five stars and whose grade of the subject is far from the grades of
+// everyone else. The score grows with that distance.
+func (detector *FraudDetector) detectExtremeReviewers(subReviewed string, reviewType domain.ReviewType, reviews []*domain.Review, histories map[string][]*domain.Review) []*domain.Suspicion {
+	var suspicions []*domain.Suspicion
+	for reviewer, history := range histories {
+		if len(history) < detector.settings.ExtremeMinReviews || !allExtreme(history) {
+			continue
+		}
+		own, others := splitByReviewer(reviews, reviewer)
+		if len(own) == 0 || len(others) < minConsensusReviews {
+			continue
+		}
+		deviation := math.Abs(float64(averageGrade(own) - averageGrade(others)))
+		if deviation < minExtremeDeviation {
+			continue
+		}
+		suspicions = append(suspicions, &domain.Suspicion{
+			Key:         subjectKey(domain.SuspicionExtremeReviewer, subReviewed, reviewType) + ":" + reviewer,
+			Kind:        domain.SuspicionExtremeReviewer,
+			SubReviewed: subReviewed,
+			Type:        reviewType,
+			Reviewer:    reviewer,
+			ReviewIds:   reviewIds(own),
+			Score:       min(1, deviation/maxGradeDeviation),
+			Details:     fmt.Sprintf("all %d reviews are 1 or 5 stars, %.1f stars away from the other reviewers", len(history), deviation),
+		})
+	}
+	return suspicions
+}
+
+// detectNearDuplicates compares MinHash signatures of comment shingles for
+// every pair of reviews written by different reviewers.
+func (detector *FraudDetector) detectNearDuplicates(subReviewed string, reviewType domain.ReviewType, reviews []*domain.Review) []*domain.Suspicion {
+	signatures := make([]text.Signature, len(reviews))
+	for i, review := range reviews {
+		shingles := text.Shingles(review.Comment, text.ShingleSize)
+		if len(shingles) >= minDuplicateShingles {
+			signatures[i] = text.MinHash(shingles, text.MinHashFunctions)
+		}
+	}
+
+	var suspicions []*domain.Suspicion
+	for i := range reviews {
+		for j := i + 1; j < len(reviews); j++ {
+			if signatures[i] == nil || signatures[j] == nil || reviews[i].SubReviewer == reviews[j].SubReviewer {
+				continue
+			}
+			similarity := signatures[i].Similarity(signatures[j])
+			if similarity < detector.settings.DuplicateSimilarity {
+				continue
+			}
+			suspicions = append(suspicions, &domain.Suspicion{
+				Key:         string(domain.SuspicionNearDuplicate) + ":" + reviews[i].Id.Hex() + ":" + reviews[j].Id.Hex(),
+				Kind:        domain.SuspicionNearDuplicate,
+				SubReviewed: subReviewed,
+				Type:        reviewType,
+				ReviewIds:   []primitive.ObjectID{reviews[i].Id, reviews[j].Id},
+				Score:       similarity,
+				Details:     fmt.Sprintf("comments are %.0f%% similar", similarity*100),
+			})
+		}
+	}
+	return suspicions
+}
+
+// detectFreshAccounts flags subjects whose recent reviews mostly come from
+// accounts younger than FreshAccountAge. Account ages come from the identity
+// provider; reviews whose reviewer's age is unknown are left out.
+func (detector *FraudDetector) detectFreshAccounts(subReviewed string, reviewType domain.ReviewType, reviews []*domain.Review) []*domain.Suspicion {
+	since := time.Now().Add(-detector.settings.FreshAccountAge)
+	var recent, fresh []*domain.Review
+	for _, review := range reviews {
+		if createdAt(review).Before(since) || review.ReviewerCreatedAt.IsZero() {
+			continue
+		}
+		recent = append(recent, review)
+		if review.ReviewerCreatedAt.After(since) {
+			fresh = append(fresh, review)
+		}
+	}
+
+	if len(fresh) < detector.settings.FreshAccountThreshold {
+		return nil
+	}
+	return []*domain.Suspicion{{
+		Key:         subjectKey(domain.SuspicionFreshAccounts, subReviewed, reviewType),
+		Kind:        domain.SuspicionFreshAccounts,
+		SubReviewed: subReviewed,
+		Type:        reviewType,
+		ReviewIds:   reviewIds(fresh),
+		Score:       float64(len(fresh)) / float64(len(recent)),
+		Details:     fmt.Sprintf("%d of %d recent reviews come from new accounts", len(fresh), len(recent)),
+	}}
+}
+
+func createdAt(review *domain.Review) time.Time {
+	return review.Id.Timestamp()
+}
+
+func allExtreme(reviews []*domain.Review) bool {
+	for _, review := range reviews {
+		if stars := domain.StarsOf(review.Grade); stars != 1 && stars != 5 {
+			return false
+		}
+	}
+	return true
+}
+
+func splitByReviewer(reviews []*domain.Review, reviewer string) ([]*domain.Review, []*domain.Review) {
+	var own, others []*domain.Review
+	for _, review := range reviews {
+		if review.SubReviewer == reviewer {
+			own = append(own, review)
+		} else {
+			others = append(others, review)
+		}
+	}
+	return own, others
+}
+
+func averageGrade(reviews []*domain.Review) float32 {
+	var total float32
+	for _, review := range reviews {
+		total += review.Grade
+	}
+	return total / float32(len(reviews))
+}
+
+func reviewIds(reviews []*domain.Review) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(reviews))
+	for _, review := range reviews {
+		ids = append(ids, review.Id)
+	}
+	return ids
+}
+
+func subjectKey(kind domain.SuspicionKind, subReviewed string, reviewType domain.ReviewType) string {
+	return string(kind) + ":" + subReviewed + ":" + strconv.Itoa(int(reviewType))
+}
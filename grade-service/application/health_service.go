@@ -0,0 +1,90 @@
+package application
+
+import (
+	"context"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"sync"
+	"time"
+)
+
+const (
+	ComponentUp   = "up"
+	ComponentDown = "down"
+)
+
+type HealthCheck func(ctx context.Context) error
+
+type component struct {
+	name  string
+	check HealthCheck
+}
+
+// HealthService checks the dependencies the service needs to serve
+// requests. Checks run concurrently and each gets the same timeout.
+type HealthService struct {
+	components []component
+	timeout    time.Duration
+}
+
+func NewHealthService(timeout time.Duration) *HealthService {
+	return &HealthService{
+		timeout: timeout,
+	}
+}
+
+func (service *HealthService) Register(name string, check HealthCheck) *HealthService {
+	service.components = append(service.components, component{name: name, check: check})
+	return service
+}
+
+func (service *HealthService) CheckReadiness(ctx context.Context) dto.ReadinessDTO {
+	readiness := dto.ReadinessDTO{
+		Status:     ComponentUp,
+		Components: make(map[string]dto.ComponentHealthDTO, len(service.components)),
+	}
+
+	var mutex sync.Mutex
+	var wait sync.WaitGroup
+	for _, c := range service.components {
+		wait.Add(1)
+		go func(c component) {
+			defer wait.Done()
+			health := service.checkComponent(ctx, c)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			readiness.Components[c.name] = health
+			if health.Status == ComponentDown {
+				readiness.Status = ComponentDown
+			}
+		}(c)
+	}
+	wait.Wait()
+	return readiness
+}
+
+func (service *HealthService) checkComponent(ctx context.Context, c component) dto.ComponentHealthDTO {
+	ctx, cancel := context.WithTimeout(ctx, service.timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() { result <- c.check(ctx) }()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	health := dto.ComponentHealthDTO{
+		Status:    ComponentUp,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		health.Status = ComponentDown
+		health.Error = err.Error()
+	}
+	return health
+}
@@ -0,0 +1,186 @@
+package application
+
+import (
+	"errors"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/metrics"
+	"github.com/mmmajder/zms-devops-grade-service/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/trace"
+	"strconv"
+	"time"
+)
+
+type ModerationService struct {
+	reviewService   *ReviewService
+	store           domain.ReviewStore
+	reportStore     domain.ReviewAbuseReportStore
+	reportThreshold int
+}
+
+func NewModerationService(reviewService *ReviewService, store domain.ReviewStore, reportStore domain.ReviewAbuseReportStore, reportThreshold int) *ModerationService {
+	return &ModerationService{
+		reviewService:   reviewService,
+		store:           store,
+		reportStore:     reportStore,
+		reportThreshold: reportThreshold,
+	}
+}
+
+func (service *ModerationService) Report(id primitive.ObjectID, reporterSub string, reason domain.ReportReason, text string, span trace.Span, loki promtail.Client) error {
+	util.HttpTraceInfo("Fetching review by id...", span, loki, "Report", "")
+	review, err := service.store.Get(id)
+	if err != nil {
+		return err
+	}
+
+	util.HttpTraceInfo("Storing review report...", span, loki, "Report", "")
+	err = service.reportStore.Insert(&domain.ReviewAbuseReport{
+		ReviewId:       id,
+		Reporter:       reporterSub,
+		Reason:         reason,
+		Text:           text,
+		DateOfCreation: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	metrics.ReviewReports.WithLabelValues(string(reason)).Inc()
+
+	reports, err := service.reportStore.CountByReview(id)
+	if err != nil {
+		return err
+	}
+	if reports < service.reportThreshold || review.Status == domain.StatusPendingModeration {
+		return nil
+	}
+
+	// The report is stored, so a failure to hide the review does not fail
+	// the request. The next report and HideReported retry the hiding.
+	util.HttpTraceInfo("Hiding review pending moderation after "+strconv.Itoa(reports)+" reports...", span, loki, "Report", "")
+	if err := service.hide(review, span, loki); err != nil {
+		util.HttpTraceError(err, "failed to hide reported review", span, loki, "Report", "")
+	}
+	return nil
+}
+
+// HideReported hides the published reviews that have reached the report
+// threshold, catching up on reports whose hiding failed.
+func (service *ModerationService) HideReported(span trace.Span, loki promtail.Client) error {
+	util.HttpTraceInfo("Fetching reported reviews...", span, loki, "HideReported", "")
+	reportedReviews, err := service.reportStore.GetReportedReviews()
+	if err != nil {
+		return err
+	}
+
+	for _, reportedReview := range reportedReviews {
+		if reportedReview.TotalReports < service.reportThreshold {
+			continue
+		}
+		review, err := service.store.Get(reportedReview.ReviewId)
+		if err != nil || review.Status == domain.StatusPendingModeration {
+			continue
+		}
+		if err := service.hide(review, span, loki); err != nil {
+			util.HttpTraceError(err, "failed to hide reported review", span, loki, "HideReported", "")
+		}
+	}
+	return nil
+}
+
+// Restore publishes a hidden review again and resolves its reports, so they
+// do not hide it again. Restoring a published review only resolves them.
+func (service *ModerationService) Restore(id primitive.ObjectID, span trace.Span, loki promtail.Client) (dto.ReviewDTO, error) {
+	util.HttpTraceInfo("Fetching review by id...", span, loki, "Restore", "")
+	review, err := service.store.Get(id)
+	if err != nil {
+		return dto.ReviewDTO{}, err
+	}
+
+	util.HttpTraceInfo("Resolving review reports...", span, loki, "Restore", "")
+	if err := service.reportStore.Resolve(id); err != nil {
+		return dto.ReviewDTO{}, err
+	}
+
+	if review.Status != domain.StatusPublished {
+		util.HttpTraceInfo("Publishing review...", span, loki, "Restore", "")
+		review, err = service.store.UpdateStatus(id, domain.StatusPublished)
+		if err != nil {
+			return dto.ReviewDTO{}, err
+		}
+		definition, err := service.reviewService.reviewTypes.Get(review.Type)
+		if err != nil {
+			return dto.ReviewDTO{}, err
+		}
+		service.reviewService.refreshRating(definition, review.SubReviewed, span, loki)
+	}
+
+	return service.reviewService.attachments.WithUrls([]dto.ReviewDTO{dto.FromReview(review)})[0], nil
+}
+
+func (service *ModerationService) GetReportedReviews(span trace.Span, loki promtail.Client) ([]dto.ReportedReviewDTO, error) {
+	util.HttpTraceInfo("Fetching reported reviews...", span, loki, "GetReportedReviews", "")
+	reportedReviews, err := service.reportStore.GetReportedReviews()
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.ReportedReviewDTO, 0, len(reportedReviews))
+	for _, reportedReview := range reportedReviews {
+		review, err := service.store.Get(reportedReview.ReviewId)
+		if errors.Is(err, domain.ErrReviewNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		reportedReviewDTO := dto.FromReportedReview(reportedReview, review)
+		reportedReviewDTO.Review = service.reviewService.attachments.WithUrls([]dto.ReviewDTO{reportedReviewDTO.Review})[0]
+		response = append(response, reportedReviewDTO)
+	}
+	return response, nil
+}
+
+// InspectAll runs the fake review detector on every reviewed subject.
+func (service *ModerationService) InspectAll(span trace.Span, loki promtail.Client) error {
+	util.HttpTraceInfo("Fetching reviewed subjects...", span, loki, "InspectAll", "")
+	subjects, err := service.store.GetSubjects()
+	if err != nil {
+		return err
+	}
+
+	for _, subject := range subjects {
+		definition, err := service.reviewService.reviewTypes.Get(subject.Type)
+		if err != nil {
+			continue
+		}
+		if service.reviewService.inspect(definition, subject.SubReviewed, span, loki) {
+			service.reviewService.refreshRating(definition, subject.SubReviewed, span, loki)
+		}
+	}
+	return nil
+}
+
+func (service *ModerationService) GetSuspicions(span trace.Span, loki promtail.Client) ([]dto.SuspicionDTO, error) {
+	util.HttpTraceInfo("Fetching suspicions...", span, loki, "GetSuspicions", "")
+	suspicions, err := service.reviewService.detector.GetSuspicions()
+	if err != nil {
+		return nil, err
+	}
+	return dto.FromSuspicions(suspicions), nil
+}
+
+func (service *ModerationService) hide(review *domain.Review, span trace.Span, loki promtail.Client) error {
+	if _, err := service.store.UpdateStatus(review.Id, domain.StatusPendingModeration); err != nil {
+		return err
+	}
+
+	definition, err := service.reviewService.reviewTypes.Get(review.Type)
+	if err != nil {
+		return err
+	}
+	service.reviewService.refreshRating(definition, review.SubReviewed, span, loki)
+	return nil
+}
@@ -1,42 +1,97 @@
 package application
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	booking "github.com/ZMS-DevOps/booking-service/proto"
 	"github.com/afiskon/promtail-client/promtail"
-	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/mmmajder/zms-devops-grade-service/application/external"
+	"github.com/mmmajder/zms-devops-grade-service/application/text"
 	"github.com/mmmajder/zms-devops-grade-service/domain"
 	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/metrics"
 	"github.com/mmmajder/zms-devops-grade-service/util"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.opentelemetry.io/otel/trace"
 	"log"
 	"net/http"
+	"sort"
+	"sync"
 	"time"
 )
 
+const (
+	snippetRadius = 60
+
+	// A review is flagged when its comment is at least this positive (or
+	// negative) while its grade sits at the opposite end of the scale.
+	sentimentMismatchScore = 0.5
+	lowGrade               = 2
+	highGrade              = 4
+
+	// patchAttempts bounds the retries of a patch sent without If-Match.
+	patchAttempts = 3
+)
+
 type ReviewService struct {
 	store         domain.ReviewStore
+	voteStore     domain.ReviewVoteStore
+	versionStore  domain.RatingVersionStore
 	HttpClient    *http.Client
-	bookingClient booking.BookingServiceClient
-	producer      *kafka.Producer
+	bookingClient external.BookingServiceClient
+	publisher     domain.EventPublisher
+	reviewTypes   *ReviewTypeRegistry
+	attachments   *AttachmentService
+	aspects       *AspectService
+	detector      *FraudDetector
+	translator    domain.Translator
 	loki          promtail.Client
+
+	// inspections holds the subjects a background inspection is running for,
+	// and whether a write arrived during the run and needs another.
+	inspectionMutex    sync.Mutex
+	inspections        map[string]bool
+	inspectionsRunning sync.WaitGroup
 }
 
-func NewReviewService(store domain.ReviewStore, httpClient *http.Client, producer *kafka.Producer, bookingClient booking.BookingServiceClient, loki promtail.Client) *ReviewService {
+func NewReviewService(store domain.ReviewStore, voteStore domain.ReviewVoteStore, versionStore domain.RatingVersionStore, httpClient *http.Client, publisher domain.EventPublisher, bookingClient external.BookingServiceClient, reviewTypes *ReviewTypeRegistry, attachments *AttachmentService, aspects *AspectService, detector *FraudDetector, translator domain.Translator, loki promtail.Client) *ReviewService {
 	return &ReviewService{
 		store:         store,
+		voteStore:     voteStore,
+		versionStore:  versionStore,
 		HttpClient:    httpClient,
 		bookingClient: bookingClient,
-		producer:      producer,
+		publisher:     publisher,
+		reviewTypes:   reviewTypes,
+		attachments:   attachments,
+		aspects:       aspects,
+		detector:      detector,
+		translator:    translator,
 		loki:          loki,
+		inspections:   make(map[string]bool),
 	}
 }
 
-func (service *ReviewService) Add(reviewType int, comment string, grade float32, reviewerSub string, reviewedSub string, fullNameReviewer string, userId string, span trace.Span, loki promtail.Client) (dto.ReviewDTO, error) {
-	if reviewCanCreate := service.userCanReview(reviewType, reviewerSub, reviewedSub, span, loki); reviewCanCreate {
+func (service *ReviewService) ReviewTypes() *ReviewTypeRegistry {
+	return service.reviewTypes
+}
+
+// Add stores a review when the reviewer has a reservation with the reviewed
+// subject. reviewerCreatedAt is the creation time of the reviewer's account,
+// or zero when the identity provider did not send it.
+func (service *ReviewService) Add(reviewType domain.ReviewType, comment string, grade float32, language string, reviewerSub string, reviewedSub string, fullNameReviewer string, userId string, reviewerCreatedAt time.Time, span trace.Span, loki promtail.Client) (dto.ReviewDTO, error) {
+	definition, err := service.reviewTypes.Get(reviewType)
+	if err != nil {
+		return dto.ReviewDTO{}, err
+	}
+
+	reviewCanCreate, err := service.userCanReview(definition, reviewerSub, reviewedSub, span, loki)
+	if err != nil {
+		return dto.ReviewDTO{}, err
+	}
+	if reviewCanCreate {
+		if language == "" {
+			language = text.DetectLanguage(comment)
+		}
 		review := &domain.Review{
 			Comment:            comment,
 			Grade:              grade,
@@ -44,20 +99,22 @@ func (service *ReviewService) Add(reviewType int, comment string, grade float32,
 			SubReviewed:        reviewedSub,
 			ReviewerFullName:   fullNameReviewer,
 			DateOfModification: time.Now(),
-			Type:               domain.ReviewType(reviewType),
+			Type:               reviewType,
+			Status:             domain.StatusPublished,
+			Language:           language,
+			Sentiment:          analyzeSentiment(comment, grade),
+			ReviewerCreatedAt:  reviewerCreatedAt,
 		}
 		util.HttpTraceInfo("Inserting review...", span, loki, "Add", "")
 		id, err := service.store.Insert(review)
 		if err != nil {
 			return dto.ReviewDTO{}, err
 		}
+		metrics.ReviewsCreated.WithLabelValues(definition.Name).Inc()
 
-		util.HttpTraceInfo("Fetching reviews by sub...", span, loki, "Add", "")
-		response, err := service.store.GetAllBySubReviewed(reviewedSub, reviewType)
-		log.Printf("new average rating %f", service.getAverageRating(response, span, loki))
-
-		service.produceRatingChanged(reviewType, reviewedSub, service.getAverageRating(response, span, loki), span)
-		service.produceNotification(reviewType, reviewedSub, fullNameReviewer, userId, span, loki)
+		service.refreshRating(definition, reviewedSub, span, loki)
+		service.produceNotification(definition, id, reviewedSub, fullNameReviewer, userId, span, loki)
+		service.inspectInBackground(definition, reviewedSub, span, loki)
 
 		reviewDTO := dto.FromReview(review)
 		reviewDTO.Id = id
@@ -65,116 +122,445 @@ func (service *ReviewService) Add(reviewType int, comment string, grade float32,
 		return reviewDTO, nil
 	}
 
-	return dto.ReviewDTO{}, errors.New("reviewer doesn't have   already exists")
+	return dto.ReviewDTO{}, domain.ErrReservationRequired
+}
+
+func (service *ReviewService) GetAllBySubReviewed(subReviewed string, reviewType domain.ReviewType, filter domain.ReviewFilter, sortOrder domain.ReviewSortOrder, translateTo string, span trace.Span, loki promtail.Client) (dto.ReviewReportDTO, error) {
+	return service.report(subReviewed, reviewType, filter, sortOrder, domain.Page{}, translateTo, span, loki)
+}
+
+// GetPageBySubReviewed returns the report of the subject with one page of the
+// reviews that match the filter.
+func (service *ReviewService) GetPageBySubReviewed(subReviewed string, reviewType domain.ReviewType, filter domain.ReviewFilter, sortOrder domain.ReviewSortOrder, page domain.Page, translateTo string, span trace.Span, loki promtail.Client) (dto.ReviewReportPageDTO, error) {
+	report, err := service.report(subReviewed, reviewType, filter, sortOrder, page, translateTo, span, loki)
+	if err != nil {
+		return dto.ReviewReportPageDTO{}, err
+	}
+	return dto.ReviewReportPageDTO{
+		ReviewReportDTO: report,
+		Page:            page.Number,
+		PageSize:        page.Size,
+		TotalPages:      page.Count(report.MatchingReviews),
+	}, nil
 }
 
-func (service *ReviewService) GetAllBySubReviewed(subReviewed string, reviewType int, span trace.Span, loki promtail.Client) (dto.ReviewReportDTO, error) {
+// report summarizes all published reviews of the subject and lists the page
+// of those matching the filter, which the store selects.
+func (service *ReviewService) report(subReviewed string, reviewType domain.ReviewType, filter domain.ReviewFilter, sortOrder domain.ReviewSortOrder, page domain.Page, translateTo string, span trace.Span, loki promtail.Client) (dto.ReviewReportDTO, error) {
+	if _, err := service.reviewTypes.Get(reviewType); err != nil {
+		return dto.ReviewReportDTO{}, err
+	}
+
 	util.HttpTraceInfo("Fetching reviews by sub...", span, loki, "GetAllBySubReviewed", "")
 	response, err := service.store.GetAllBySubReviewed(subReviewed, reviewType)
 	if err != nil {
 		return dto.ReviewReportDTO{}, err
 	}
+	ratingVersion, err := service.versionStore.Get(subReviewed, reviewType)
+	if err != nil {
+		return dto.ReviewReportDTO{}, err
+	}
 	averageRating, numberOfStars := service.getReviewReportData(response, span, loki)
+	sentimentDistribution := getSentimentDistribution(response)
+	totalReviews := len(response)
+	if !filter.IsEmpty() {
+		util.HttpTraceInfo("Fetching matching reviews by sub...", span, loki, "GetAllBySubReviewed", "")
+		response, err = service.store.GetMatchingBySubReviewed(subReviewed, reviewType, filter)
+		if err != nil {
+			return dto.ReviewReportDTO{}, err
+		}
+	}
+	sortReviews(response, sortOrder)
+	matchingReviews := len(response)
+	start, end := page.Bounds(matchingReviews)
+	response = response[start:end]
+
+	reviews := service.attachments.WithUrls(*dto.FromReviews(response))
+	if translateTo != "" {
+		service.translate(reviews, translateTo, span, loki)
+	}
+
+	util.HttpTraceInfo("Fetching review aspects...", span, loki, "GetAllBySubReviewed", "")
+	topPositive, topNegative, err := service.aspects.GetAspects(subReviewed, reviewType)
+	if err != nil {
+		return dto.ReviewReportDTO{}, err
+	}
 
 	reviewReportDTO := dto.ReviewReportDTO{
-		TotalReviews:  len(response),
-		AverageRating: averageRating,
-		NumberOfStars: numberOfStars,
-		Reviews:       *dto.FromReviews(response),
+		TotalReviews:       totalReviews,
+		MatchingReviews:    matchingReviews,
+		AverageRating:      averageRating,
+		RatingVersion:      ratingVersion,
+		NumberOfStars:      numberOfStars,
+		Sentiment:          sentimentDistribution,
+		Reviews:            reviews,
+		TopPositiveAspects: topPositive,
+		TopNegativeAspects: topNegative,
 	}
 
 	return reviewReportDTO, nil
 }
 
-func (service *ReviewService) Update(id primitive.ObjectID, reviewType int, comment string, grade float32, span trace.Span, loki promtail.Client) error {
+func (service *ReviewService) Search(subReviewed string, reviewType domain.ReviewType, query string, filter domain.ReviewFilter, limit int, span trace.Span, loki promtail.Client) (dto.ReviewSearchDTO, error) {
+	if _, err := service.reviewTypes.Get(reviewType); err != nil {
+		return dto.ReviewSearchDTO{}, err
+	}
+
+	util.HttpTraceInfo("Searching reviews by sub...", span, loki, "Search", "")
+	results, total, err := service.store.Search(subReviewed, reviewType, query, filter, limit)
+	if err != nil {
+		return dto.ReviewSearchDTO{}, err
+	}
+
+	response := dto.ReviewSearchDTO{
+		Query:        query,
+		TotalResults: total,
+		Results:      make([]dto.ReviewSearchResultDTO, 0, len(results)),
+	}
+	for _, result := range results {
+		fragments := text.Highlight(result.Review.Comment, query, snippetRadius)
+		snippet := make([]dto.SnippetFragmentDTO, 0, len(fragments))
+		for _, fragment := range fragments {
+			snippet = append(snippet, dto.SnippetFragmentDTO{Text: fragment.Text, Match: fragment.Match})
+		}
+		response.Results = append(response.Results, dto.ReviewSearchResultDTO{
+			Review:  service.attachments.WithUrls([]dto.ReviewDTO{dto.FromReview(result.Review)})[0],
+			Score:   result.Score,
+			Snippet: snippet,
+		})
+	}
+	return response, nil
+}
+
+// Get returns a published review; hidden reviews are not found.
+func (service *ReviewService) Get(id primitive.ObjectID, span trace.Span, loki promtail.Client) (dto.ReviewDTO, error) {
+	util.HttpTraceInfo("Fetching review by id...", span, loki, "Get", "")
+	review, err := service.store.Get(id)
+	if err != nil {
+		return dto.ReviewDTO{}, err
+	}
+	if review.Status == domain.StatusPendingModeration {
+		return dto.ReviewDTO{}, domain.ErrReviewNotFound
+	}
+	return service.attachments.WithUrls([]dto.ReviewDTO{dto.FromReview(review)})[0], nil
+}
+
+// Update changes the review only while its version is one of versions; a nil
+// versions skips the check. The rating is refreshed for the type the review
+// was stored with.
+func (service *ReviewService) Update(id primitive.ObjectID, comment string, grade float32, versions []int64, span trace.Span, loki promtail.Client) (dto.ReviewDTO, error) {
+	util.HttpTraceInfo("Fetching review by id...", span, loki, "Update", "")
+	stored, err := service.store.Get(id)
+	if err != nil {
+		return dto.ReviewDTO{}, err
+	}
+
 	util.HttpTraceInfo("Updating reviews...", span, loki, "Update", "")
-	review, err := service.store.Update(id, comment, grade)
+	language := languageAfterEdit(stored, comment)
+	review, err := service.store.Update(id, comment, grade, language, analyzeSentiment(comment, grade), versions)
 	if err != nil {
-		return err
+		return dto.ReviewDTO{}, err
+	}
+	definition, err := service.reviewTypes.Get(review.Type)
+	if err != nil {
+		return dto.ReviewDTO{}, err
 	}
 
-	util.HttpTraceInfo("Fetching reviews by sub...", span, loki, "Update", "")
-	response, err := service.store.GetAllBySubReviewed(review.SubReviewed, reviewType)
-	log.Printf("new average rating %f", service.getAverageRating(response, span, loki))
+	service.refreshRating(definition, review.SubReviewed, span, loki)
+	service.inspectInBackground(definition, review.SubReviewed, span, loki)
 
-	service.produceRatingChanged(reviewType, review.SubReviewed, service.getAverageRating(response, span, loki), span)
+	return service.attachments.WithUrls([]dto.ReviewDTO{dto.FromReview(review)})[0], nil
+}
 
-	return nil
+// ReviewTypeOf returns the type of the stored review, for callers that
+// identify a review by its id alone.
+func (service *ReviewService) ReviewTypeOf(id primitive.ObjectID) (ReviewTypeDefinition, error) {
+	review, err := service.store.Get(id)
+	if err != nil {
+		return ReviewTypeDefinition{}, err
+	}
+	return service.reviewTypes.Get(review.Type)
+}
+
+// Patch applies a partial update on top of the stored review. Without
+// versions it retries when another change lands between reading and writing
+// the review; with versions a concurrent change fails the patch instead. The
+// rating is refreshed only when the grade changes.
+func (service *ReviewService) Patch(id primitive.ObjectID, patch domain.ReviewPatch, versions []int64, span trace.Span, loki promtail.Client) (dto.ReviewDTO, error) {
+	for attempt := 1; ; attempt++ {
+		util.HttpTraceInfo("Fetching review by id...", span, loki, "Patch", "")
+		review, err := service.store.Get(id)
+		if err != nil {
+			return dto.ReviewDTO{}, err
+		}
+		definition, err := service.reviewTypes.Get(review.Type)
+		if err != nil {
+			return dto.ReviewDTO{}, err
+		}
+		if patch.IsEmpty() {
+			if versions != nil && !containsVersion(versions, review.Version) {
+				return dto.ReviewDTO{}, domain.ErrVersionMismatch
+			}
+			return service.attachments.WithUrls([]dto.ReviewDTO{dto.FromReview(review)})[0], nil
+		}
+
+		comment, grade, language := review.Comment, review.Grade, review.Language
+		if patch.Comment != nil {
+			comment = *patch.Comment
+		}
+		if patch.Grade != nil {
+			grade = *patch.Grade
+		}
+		switch {
+		case patch.Language != nil:
+			language = *patch.Language
+		case patch.DetectLanguage:
+			language = text.DetectLanguage(comment)
+		default:
+			language = languageAfterEdit(review, comment)
+		}
+
+		expected := versions
+		if expected == nil {
+			expected = []int64{review.Version}
+		}
+		util.HttpTraceInfo("Patching review...", span, loki, "Patch", "")
+		updated, err := service.store.Update(id, comment, grade, language, analyzeSentiment(comment, grade), expected)
+		if errors.Is(err, domain.ErrVersionMismatch) && versions == nil && attempt < patchAttempts {
+			continue
+		}
+		if err != nil {
+			return dto.ReviewDTO{}, err
+		}
+
+		if grade != review.Grade {
+			service.refreshRating(definition, updated.SubReviewed, span, loki)
+		}
+		service.inspectInBackground(definition, updated.SubReviewed, span, loki)
+
+		return service.attachments.WithUrls([]dto.ReviewDTO{dto.FromReview(updated)})[0], nil
+	}
 }
 
-func (service *ReviewService) Delete(id primitive.ObjectID, reviewType int, span trace.Span, loki promtail.Client) error {
+func (service *ReviewService) Delete(id primitive.ObjectID, span trace.Span, loki promtail.Client) error {
 	util.HttpTraceInfo("Fetching review by id...", span, loki, "Delete", "")
 	review, err := service.store.Get(id)
 	if err != nil {
 		return err
 	}
+	definition, err := service.reviewTypes.Get(review.Type)
+	if err != nil {
+		return err
+	}
 	util.HttpTraceInfo("Deleting review by id...", span, loki, "Delete", "")
 	if err = service.store.Delete(id); err != nil {
 		return err
 	}
+	metrics.ReviewsDeleted.WithLabelValues(definition.Name).Inc()
+
+	util.HttpTraceInfo("Deleting review votes...", span, loki, "Delete", "")
+	if err = service.voteStore.DeleteByReview(id); err != nil {
+		return err
+	}
+
+	util.HttpTraceInfo("Deleting review attachments...", span, loki, "Delete", "")
+	service.attachments.DeleteAll(review, span, loki)
 
-	util.HttpTraceInfo("Fetching reviews by sub...", span, loki, "Delete", "")
-	response, err := service.store.GetAllBySubReviewed(review.SubReviewed, reviewType)
-	service.produceRatingChanged(reviewType, review.SubReviewed, service.getAverageRating(response, span, loki), span)
+	service.refreshRating(definition, review.SubReviewed, span, loki)
 
 	return nil
 }
 
-func (service *ReviewService) produceRatingChanged(reviewType int, reviewedId string, rating float32, span trace.Span) {
-	var topic string
-	if reviewType == 0 {
-		topic = "host-rating.changed"
-	} else {
-		topic = "accommodation-rating.changed"
+func (service *ReviewService) Vote(id primitive.ObjectID, voterSub string, helpful bool, span trace.Span, loki promtail.Client) (dto.ReviewDTO, error) {
+	util.HttpTraceInfo("Fetching review by id...", span, loki, "Vote", "")
+	review, err := service.store.Get(id)
+	if err != nil {
+		return dto.ReviewDTO{}, err
+	}
+	if review.SubReviewer == voterSub {
+		return dto.ReviewDTO{}, domain.ErrOwnReviewVote
+	}
+
+	util.HttpTraceInfo("Storing review vote...", span, loki, "Vote", "")
+	previous, err := service.voteStore.Upsert(&domain.ReviewVote{
+		ReviewId:           id,
+		Voter:              voterSub,
+		Helpful:            helpful,
+		DateOfModification: time.Now(),
+	})
+	if err != nil {
+		return dto.ReviewDTO{}, err
 	}
 
-	ratingChangedDTO := dto.RatingChangedDTO{
-		Id:     reviewedId,
-		Rating: rating,
+	if previous == nil || previous.Helpful != helpful {
+		metrics.ReviewVotes.WithLabelValues(voteLabel(helpful)).Inc()
 	}
-	message, _ := json.Marshal(ratingChangedDTO)
-	err := service.producer.Produce(&kafka.Message{
-		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
-		Value:          message,
-	}, nil)
 
+	// The counts are recounted from the votes rather than incremented, so a
+	// vote whose count was lost to a crash or a concurrent vote is repaired
+	// by the next vote on the review, a retry included.
+	util.HttpTraceInfo("Counting review votes...", span, loki, "Vote", "")
+	helpfulCount, notHelpfulCount, err := service.voteStore.Count(id)
 	if err != nil {
-		log.Fatalf("Failed to produce message: %s", err)
+		return dto.ReviewDTO{}, err
+	}
+	if helpfulCount != review.HelpfulCount || notHelpfulCount != review.NotHelpfulCount {
+		util.HttpTraceInfo("Updating review vote counts...", span, loki, "Vote", "")
+		review, err = service.store.SetVoteCounts(id, helpfulCount, notHelpfulCount)
+		if err != nil {
+			return dto.ReviewDTO{}, err
+		}
 	}
 
-	service.producer.Flush(4 * 1000)
+	return service.attachments.WithUrls([]dto.ReviewDTO{dto.FromReview(review)})[0], nil
 }
 
-func (service *ReviewService) produceNotification(reviewType int, reviewedId string, reviewerName string, userId string, span trace.Span, loki promtail.Client) {
-	var topic string
-	var notificationDTO dto.NotificationDTO
-	if reviewType == 0 {
-		topic = "host-review.created"
-		notificationDTO = dto.NotificationDTO{
-			UserId:       userId,
-			ReviewerName: reviewerName,
+// inspectInBackground runs inspect for the subject after a write without
+// holding up the request; the detector reads every review of the subject and
+// of its reviewers. Writes to a subject that is being inspected ask for one
+// more run instead of starting runs side by side.
+func (service *ReviewService) inspectInBackground(definition ReviewTypeDefinition, subReviewed string, span trace.Span, loki promtail.Client) {
+	key := definition.Name + ":" + subReviewed
+	service.inspectionMutex.Lock()
+	defer service.inspectionMutex.Unlock()
+	if _, running := service.inspections[key]; running {
+		service.inspections[key] = true
+		return
+	}
+	service.inspections[key] = false
+
+	service.inspectionsRunning.Add(1)
+	go func() {
+		defer service.inspectionsRunning.Done()
+		ctx := trace.ContextWithSpan(context.Background(), span)
+		_, span := span.TracerProvider().Tracer(domain.ServiceName).Start(ctx, "inspect-reviews")
+		defer func() { span.End() }()
+		for {
+			if service.inspect(definition, subReviewed, span, loki) {
+				service.refreshRating(definition, subReviewed, span, loki)
+			}
+			if !service.nextInspection(key) {
+				return
+			}
 		}
-	} else {
-		topic = "accommodation-review.created"
-		notificationDTO = dto.NotificationDTO{
-			UserId:          userId,
-			AccommodationId: reviewedId,
-			ReviewerName:    reviewerName,
+	}()
+}
+
+// nextInspection reports whether the subject was written to during the last
+// run, and forgets the subject when it was not.
+func (service *ReviewService) nextInspection(key string) bool {
+	service.inspectionMutex.Lock()
+	defer service.inspectionMutex.Unlock()
+	if service.inspections[key] {
+		service.inspections[key] = false
+		return true
+	}
+	delete(service.inspections, key)
+	return false
+}
+
+// WaitForInspections blocks until the background inspections started by
+// writes are done.
+func (service *ReviewService) WaitForInspections() {
+	service.inspectionsRunning.Wait()
+}
+
+// inspect runs the fake review detector on a subject and flags the reviews
+// involved in its findings for moderators. High scoring findings also hide
+// the reviews when the detector is allowed to. It reports whether any review
+// was hidden; callers refresh the rating afterwards.
+func (service *ReviewService) inspect(definition ReviewTypeDefinition, subReviewed string, span trace.Span, loki promtail.Client) bool {
+	util.HttpTraceInfo("Inspecting reviews for manipulation...", span, loki, "inspect", "")
+	suspicions, err := service.detector.Inspect(subReviewed, definition.Type)
+	if err != nil {
+		util.HttpTraceError(err, "failed to inspect reviews", span, loki, "inspect", "")
+		return false
+	}
+
+	hidden := false
+	for _, suspicion := range suspicions {
+		for _, id := range suspicion.ReviewIds {
+			if _, err := service.store.Flag(id); err != nil {
+				util.HttpTraceError(err, "failed to flag suspicious review", span, loki, "inspect", "")
+			}
+		}
+		if !service.detector.ShouldHide(suspicion) {
+			continue
+		}
+		for _, id := range suspicion.ReviewIds {
+			if _, err := service.store.UpdateStatus(id, domain.StatusPendingModeration); err != nil {
+				util.HttpTraceError(err, "failed to hide suspicious review", span, loki, "inspect", "")
+				continue
+			}
+			hidden = true
 		}
 	}
-	util.HttpTraceInfo("Producing notification for "+topic+"...", span, loki, "produceNotification", "")
+	return hidden
+}
 
-	message, _ := json.Marshal(notificationDTO)
-	err := service.producer.Produce(&kafka.Message{
-		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
-		Value:          message,
-	}, nil)
+// refreshRating recalculates the average of the published reviews of a
+// subject and announces it to the other services. The version is taken before
+// the reviews are read, so a rating with a higher version never misses a
+// change that a lower one saw.
+func (service *ReviewService) refreshRating(definition ReviewTypeDefinition, subReviewed string, span trace.Span, loki promtail.Client) {
+	util.HttpTraceInfo("Incrementing rating version...", span, loki, "refreshRating", "")
+	version, err := service.versionStore.Next(subReviewed, definition.Type)
+	if err != nil {
+		util.HttpTraceError(err, "failed to increment rating version", span, loki, "refreshRating", "")
+		return
+	}
 
+	util.HttpTraceInfo("Fetching reviews by sub...", span, loki, "refreshRating", "")
+	response, err := service.store.GetAllBySubReviewed(subReviewed, definition.Type)
 	if err != nil {
-		log.Fatalf("Failed to produce message: %s", err)
+		util.HttpTraceError(err, "failed to fetch reviews for rating", span, loki, "refreshRating", "")
+		return
+	}
+
+	averageRating := service.getAverageRating(response, span, loki)
+	log.Printf("new average rating %f", averageRating)
+	service.produceRatingChanged(definition, subReviewed, averageRating, version, span, loki)
+}
+
+func (service *ReviewService) produceRatingChanged(definition ReviewTypeDefinition, reviewedId string, rating float32, version int64, span trace.Span, loki promtail.Client) {
+	service.publish(domain.Event{
+		Topic:      definition.RatingChangedTopic,
+		Key:        reviewedId,
+		Type:       domain.RatingChangedEventType,
+		Subject:    reviewedId,
+		DataSchema: domain.RatingChangedSchema,
+		Data: dto.RatingChangedDTO{
+			Id:      reviewedId,
+			Rating:  rating,
+			Version: version,
+		},
+	}, span, loki)
+}
+
+func (service *ReviewService) produceNotification(definition ReviewTypeDefinition, reviewId primitive.ObjectID, reviewedId string, reviewerName string, userId string, span trace.Span, loki promtail.Client) {
+	if definition.Notification == nil {
+		return
 	}
+	topic := definition.ReviewCreatedTopic
+	util.HttpTraceInfo("Producing notification for "+topic+"...", span, loki, "produceNotification", "")
+
+	service.publish(domain.Event{
+		Topic:      topic,
+		Key:        reviewedId,
+		Type:       domain.ReviewCreatedEventType,
+		Subject:    reviewId.Hex(),
+		DataSchema: domain.ReviewCreatedSchema,
+		Data:       definition.Notification(reviewedId, reviewerName, userId),
+	}, span, loki)
+}
 
-	service.producer.Flush(4 * 1000)
+// publish logs failed deliveries instead of failing the request; the review
+// itself is already stored.
+func (service *ReviewService) publish(event domain.Event, span trace.Span, loki promtail.Client) {
+	ctx := trace.ContextWithSpan(context.Background(), span)
+	if err := service.publisher.Publish(ctx, event); err != nil {
+		util.HttpTraceError(err, "failed to publish event to "+event.Topic, span, loki, "publish", "")
+	}
 }
 
 func (service *ReviewService) getReviewReportData(reviews []*domain.Review, span trace.Span, loki promtail.Client) (float32, []dto.NumberOfStars) {
@@ -187,22 +573,48 @@ func (service *ReviewService) getReviewReportData(reviews []*domain.Review, span
 
 	for _, review := range reviews {
 		totalGrades += review.Grade
-		gradeCounts[ratingToIndex(review.Grade)]++
+		gradeCounts[domain.StarsOf(review.Grade)-1]++
 	}
 
 	averageGrade := totalGrades / float32(len(reviews))
 
 	numberOfStars := []dto.NumberOfStars{
-		{"1", gradeCounts[0]},
-		{"2", gradeCounts[1]},
-		{"3", gradeCounts[2]},
-		{"4", gradeCounts[3]},
-		{"5", gradeCounts[4]},
+		{Label: "1", Value: gradeCounts[0]},
+		{Label: "2", Value: gradeCounts[1]},
+		{Label: "3", Value: gradeCounts[2]},
+		{Label: "4", Value: gradeCounts[3]},
+		{Label: "5", Value: gradeCounts[4]},
 	}
 
 	return averageGrade, numberOfStars
 }
 
+func getSentimentDistribution(reviews []*domain.Review) dto.SentimentDistributionDTO {
+	var distribution dto.SentimentDistributionDTO
+	for _, review := range reviews {
+		switch review.Sentiment.Label() {
+		case domain.SentimentPositive:
+			distribution.Positive++
+		case domain.SentimentNegative:
+			distribution.Negative++
+		default:
+			distribution.Neutral++
+		}
+		if review.Sentiment.Mismatch {
+			distribution.Mismatched++
+		}
+	}
+	return distribution
+}
+
+func analyzeSentiment(comment string, grade float32) domain.Sentiment {
+	score := text.Sentiment(comment)
+	return domain.Sentiment{
+		Score:    score,
+		Mismatch: (score >= sentimentMismatchScore && grade <= lowGrade) || (score <= -sentimentMismatchScore && grade >= highGrade),
+	}
+}
+
 func (service *ReviewService) getAverageRating(reviews []*domain.Review, span trace.Span, loki promtail.Client) float32 {
 	util.HttpTraceInfo("Calculating average rating...", span, loki, "getAverageRating", "")
 	var totalGrades float32
@@ -217,39 +629,81 @@ func (service *ReviewService) getAverageRating(reviews []*domain.Review, span tr
 	return totalGrades / float32(len(reviews))
 }
 
-func (service *ReviewService) userCanReview(reviewType int, reviewerSub string, reviewedSub string, span trace.Span, loki promtail.Client) bool {
-	var canReview bool
-	log.Printf("type %d", reviewType)
-	if reviewType == 0 {
-		response, err := external.IfGuestCanReviewHost(service.bookingClient, reviewerSub, reviewedSub, span, loki)
-		if err != nil {
-			return false
+func (service *ReviewService) userCanReview(definition ReviewTypeDefinition, reviewerSub string, reviewedSub string, span trace.Span, loki promtail.Client) (bool, error) {
+	canReview, err := definition.CanReview(service.bookingClient, reviewerSub, reviewedSub, span, loki)
+	if err != nil {
+		return false, domain.NewUpstreamError("booking_unavailable", "could not check the reservation with the booking service", err)
+	}
+
+	return canReview, nil
+}
+
+// translate fills in translated comments, leaving the comment untranslated
+// when the translator fails so the listing still succeeds. Without a
+// translation backend no review gets a translatedComment.
+func (service *ReviewService) translate(reviews []dto.ReviewDTO, targetLanguage string, span trace.Span, loki promtail.Client) {
+	util.HttpTraceInfo("Translating reviews to "+targetLanguage+"...", span, loki, "translate", "")
+	for i := range reviews {
+		if reviews[i].Language == targetLanguage {
+			continue
+		}
+		translated, err := service.translator.Translate(reviews[i].Comment, reviews[i].Language, targetLanguage)
+		if errors.Is(err, domain.ErrTranslationUnavailable) {
+			return
 		}
-		canReview = response.HasReservation
-	} else {
-		response, err := external.IfGuestCanReviewAccommodation(service.bookingClient, reviewerSub, reviewedSub, span, loki)
 		if err != nil {
-			return false
+			util.HttpTraceError(err, "failed to translate review", span, loki, "translate", "")
+			continue
+		}
+		if translated != "" {
+			reviews[i].TranslatedComment = translated
 		}
-		canReview = response.HasReservation
 	}
+}
 
-	return canReview
+// languageAfterEdit keeps the stored language unless the comment changed and
+// the new comment is long enough to detect its language.
+func languageAfterEdit(review *domain.Review, comment string) string {
+	if comment == review.Comment {
+		return review.Language
+	}
+	if detected := text.DetectLanguage(comment); detected != "" {
+		return detected
+	}
+	return review.Language
 }
 
-func ratingToIndex(rating float32) int {
-	switch {
-	case rating <= 1:
-		return 0
-	case rating <= 2:
-		return 1
-	case rating <= 3:
-		return 2
-	case rating <= 4:
-		return 3
-	case rating <= 5:
-		return 4
-	default:
-		return 4
+func voteLabel(helpful bool) string {
+	if helpful {
+		return "helpful"
+	}
+	return "not_helpful"
+}
+
+func sortReviews(reviews []*domain.Review, sortOrder domain.ReviewSortOrder) {
+	switch sortOrder {
+	case domain.SortNewest:
+		sort.SliceStable(reviews, func(i, j int) bool {
+			return reviews[i].DateOfModification.After(reviews[j].DateOfModification)
+		})
+	case domain.SortMostHelpful:
+		sort.SliceStable(reviews, func(i, j int) bool {
+			if reviews[i].HelpfulCount != reviews[j].HelpfulCount {
+				return reviews[i].HelpfulCount > reviews[j].HelpfulCount
+			}
+			if reviews[i].NotHelpfulCount != reviews[j].NotHelpfulCount {
+				return reviews[i].NotHelpfulCount < reviews[j].NotHelpfulCount
+			}
+			return reviews[i].DateOfModification.After(reviews[j].DateOfModification)
+		})
+	}
+}
+
+func containsVersion(versions []int64, version int64) bool {
+	for _, candidate := range versions {
+		if candidate == version {
+			return true
+		}
 	}
+	return false
 }
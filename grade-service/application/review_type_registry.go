@@ -0,0 +1,200 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/mmmajder/zms-devops-grade-service/application/external"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"go.opentelemetry.io/otel/trace"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type EligibilityChecker func(bookingClient external.BookingServiceClient, reviewerSub string, reviewedSub string, span trace.Span, loki promtail.Client) (bool, error)
+
+type NotificationBuilder func(reviewedSub string, reviewerName string, userId string) dto.NotificationDTO
+
+// ReviewTypeDefinition describes everything that differs between review
+// types. A nil Notification means no notification is sent for the type.
+type ReviewTypeDefinition struct {
+	Type               domain.ReviewType
+	Name               string
+	RatingChangedTopic string
+	ReviewCreatedTopic string
+	ReviewerRole       string
+	Criteria           []string
+	CanReview          EligibilityChecker
+	Notification       NotificationBuilder
+}
+
+type ReviewTypeRegistry struct {
+	byType map[domain.ReviewType]ReviewTypeDefinition
+	byName map[string]domain.ReviewType
+}
+
+func NewReviewTypeRegistry() *ReviewTypeRegistry {
+	return &ReviewTypeRegistry{
+		byType: make(map[domain.ReviewType]ReviewTypeDefinition),
+		byName: make(map[string]domain.ReviewType),
+	}
+}
+
+// ReviewTopics names the topics the built-in review types publish to.
+type ReviewTopics struct {
+	HostRatingChanged          string
+	HostReviewCreated          string
+	AccommodationRatingChanged string
+	AccommodationReviewCreated string
+	GuestRatingChanged         string
+}
+
+func DefaultReviewTypeRegistry(topics ReviewTopics) (*ReviewTypeRegistry, error) {
+	host := hostReviewType
+	host.RatingChangedTopic = topics.HostRatingChanged
+	host.ReviewCreatedTopic = topics.HostReviewCreated
+
+	accommodation := accommodationReviewType
+	accommodation.RatingChangedTopic = topics.AccommodationRatingChanged
+	accommodation.ReviewCreatedTopic = topics.AccommodationReviewCreated
+
+	guest := guestReviewType
+	guest.RatingChangedTopic = topics.GuestRatingChanged
+
+	registry := NewReviewTypeRegistry()
+	for _, definition := range []ReviewTypeDefinition{host, accommodation, guest} {
+		if err := registry.Register(definition); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+func (registry *ReviewTypeRegistry) Register(definition ReviewTypeDefinition) error {
+	name := strings.ToLower(definition.Name)
+	if name == "" {
+		return errors.New("review type name can not be empty")
+	}
+	if definition.CanReview == nil {
+		return fmt.Errorf("review type %s has no eligibility checker", name)
+	}
+	if _, exists := registry.byType[definition.Type]; exists {
+		return fmt.Errorf("review type %d is already registered", definition.Type)
+	}
+	if _, exists := registry.byName[name]; exists {
+		return fmt.Errorf("review type %s is already registered", name)
+	}
+
+	definition.Name = name
+	registry.byType[definition.Type] = definition
+	registry.byName[name] = definition.Type
+	return nil
+}
+
+func (registry *ReviewTypeRegistry) Get(reviewType domain.ReviewType) (ReviewTypeDefinition, error) {
+	definition, ok := registry.byType[reviewType]
+	if !ok {
+		return ReviewTypeDefinition{}, domain.NewValidationErrorf("unknown_review_type", "unknown review type %d", reviewType)
+	}
+	return definition, nil
+}
+
+// Parse resolves a review type by its name or, for older clients, by its
+// numeric value.
+func (registry *ReviewTypeRegistry) Parse(value string) (ReviewTypeDefinition, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if reviewType, ok := registry.byName[value]; ok {
+		return registry.byType[reviewType], nil
+	}
+	if number, err := strconv.Atoi(value); err == nil {
+		return registry.Get(domain.ReviewType(number))
+	}
+	return ReviewTypeDefinition{}, domain.NewValidationErrorf("unknown_review_type", "unknown review type %q", value)
+}
+
+// ParseName resolves a review type by its name only, as v2 of the API
+// requires.
+func (registry *ReviewTypeRegistry) ParseName(value string) (ReviewTypeDefinition, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if reviewType, ok := registry.byName[value]; ok {
+		return registry.byType[reviewType], nil
+	}
+	return ReviewTypeDefinition{}, domain.NewValidationErrorf("unknown_review_type", "unknown review type %q", value)
+}
+
+func (registry *ReviewTypeRegistry) All() []ReviewTypeDefinition {
+	definitions := make([]ReviewTypeDefinition, 0, len(registry.byType))
+	for _, definition := range registry.byType {
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Type < definitions[j].Type
+	})
+	return definitions
+}
+
+func (definition ReviewTypeDefinition) ToDTO() dto.ReviewTypeDTO {
+	return dto.ReviewTypeDTO{
+		Id:           int(definition.Type),
+		Name:         definition.Name,
+		ReviewerRole: definition.ReviewerRole,
+		Criteria:     definition.Criteria,
+	}
+}
+
+var hostReviewType = ReviewTypeDefinition{
+	Type:         domain.Host,
+	Name:         "host",
+	ReviewerRole: domain.GuestRole,
+	Criteria:     []string{"communication", "hospitality", "check-in"},
+	CanReview: func(bookingClient external.BookingServiceClient, reviewerSub string, reviewedSub string, span trace.Span, loki promtail.Client) (bool, error) {
+		response, err := external.IfGuestCanReviewHost(bookingClient, reviewerSub, reviewedSub, span, loki)
+		if err != nil {
+			return false, err
+		}
+		return response.HasReservation, nil
+	},
+	Notification: func(reviewedSub string, reviewerName string, userId string) dto.NotificationDTO {
+		return dto.NotificationDTO{
+			UserId:       userId,
+			ReviewerName: reviewerName,
+		}
+	},
+}
+
+var accommodationReviewType = ReviewTypeDefinition{
+	Type:         domain.Accommodation,
+	Name:         "accommodation",
+	ReviewerRole: domain.GuestRole,
+	Criteria:     []string{"cleanliness", "location", "comfort", "value"},
+	CanReview: func(bookingClient external.BookingServiceClient, reviewerSub string, reviewedSub string, span trace.Span, loki promtail.Client) (bool, error) {
+		response, err := external.IfGuestCanReviewAccommodation(bookingClient, reviewerSub, reviewedSub, span, loki)
+		if err != nil {
+			return false, err
+		}
+		return response.HasReservation, nil
+	},
+	Notification: func(reviewedSub string, reviewerName string, userId string) dto.NotificationDTO {
+		return dto.NotificationDTO{
+			UserId:          userId,
+			AccommodationId: reviewedSub,
+			ReviewerName:    reviewerName,
+		}
+	},
+}
+
+var guestReviewType = ReviewTypeDefinition{
+	Type:         domain.Guest,
+	Name:         "guest",
+	ReviewerRole: domain.HostRole,
+	Criteria:     []string{"communication", "cleanliness", "house-rules"},
+	CanReview: func(bookingClient external.BookingServiceClient, reviewerSub string, reviewedSub string, span trace.Span, loki promtail.Client) (bool, error) {
+		response, err := external.IfHostCanReviewGuest(bookingClient, reviewerSub, reviewedSub, span, loki)
+		if err != nil {
+			return false, err
+		}
+		return response.HasReservation, nil
+	},
+}
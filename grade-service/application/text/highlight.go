@@ -0,0 +1,153 @@
+package text
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type Fragment struct {
+	Text  string
+	Match bool
+}
+
+// Highlight cuts a snippet of about radius runes on each side of the first
+// query term found in the text and splits it into matching and plain
+// fragments. Terms and phrases match whole words, ignoring case.
+func Highlight(value string, query string, radius int) []Fragment {
+	runes := []rune(value)
+	lowered := make([]rune, len(runes))
+	for i, r := range runes {
+		lowered[i] = unicode.ToLower(r)
+	}
+	terms := queryTerms(query)
+
+	type match struct{ start, end int }
+	var matches []match
+	for i := 0; i < len(lowered); i++ {
+		if i > 0 && isWordRune(lowered[i-1]) {
+			continue
+		}
+		for _, term := range terms {
+			end := i + len(term)
+			if end > len(lowered) || string(lowered[i:end]) != string(term) {
+				continue
+			}
+			if end < len(lowered) && isWordRune(lowered[end]) {
+				continue
+			}
+			matches = append(matches, match{i, end})
+			i = end - 1
+			break
+		}
+	}
+
+	start, end := 0, len(runes)
+	if len(matches) > 0 {
+		start = wordStart(runes, max(0, matches[0].start-radius))
+		end = wordEnd(runes, min(len(runes), matches[0].end+radius))
+	} else if len(runes) > 2*radius {
+		end = wordEnd(runes, 2*radius)
+	}
+
+	var fragments []Fragment
+	if start > 0 {
+		fragments = append(fragments, Fragment{Text: "…"})
+	}
+	position := start
+	for _, m := range matches {
+		if m.start < start || m.end > end {
+			continue
+		}
+		if m.start > position {
+			fragments = append(fragments, Fragment{Text: string(runes[position:m.start])})
+		}
+		fragments = append(fragments, Fragment{Text: string(runes[m.start:m.end]), Match: true})
+		position = m.end
+	}
+	if position < end {
+		fragments = append(fragments, Fragment{Text: string(runes[position:end])})
+	}
+	if end < len(runes) {
+		fragments = append(fragments, Fragment{Text: "…"})
+	}
+	return mergePlain(fragments)
+}
+
+// queryTerms reads a query the way the Mongo text index does: a quoted
+// phrase is one term, and terms or phrases prefixed with "-" are negated and
+// never highlighted. Longer terms come first so a phrase wins over its words.
+func queryTerms(query string) [][]rune {
+	var terms [][]rune
+	runes := []rune(strings.ToLower(query))
+	for i := 0; i < len(runes); i++ {
+		if unicode.IsSpace(runes[i]) {
+			continue
+		}
+		negated := runes[i] == '-'
+		if negated {
+			i++
+		}
+		if i >= len(runes) || unicode.IsSpace(runes[i]) {
+			continue
+		}
+		if runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if phrase := strings.Join(strings.FieldsFunc(string(runes[i+1:end]), unicode.IsSpace), " "); !negated && phrase != "" {
+				terms = append(terms, []rune(phrase))
+			}
+			i = end
+			continue
+		}
+		end := i
+		for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+			end++
+		}
+		if !negated {
+			for _, word := range strings.FieldsFunc(string(runes[i:end]), func(r rune) bool {
+				return !isWordRune(r)
+			}) {
+				terms = append(terms, []rune(word))
+			}
+		}
+		i = end - 1
+	}
+	sort.SliceStable(terms, func(i, j int) bool {
+		return len(terms[i]) > len(terms[j])
+	})
+	return terms
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func wordStart(runes []rune, index int) int {
+	for index > 0 && isWordRune(runes[index-1]) {
+		index--
+	}
+	return index
+}
+
+func wordEnd(runes []rune, index int) int {
+	for index < len(runes) && isWordRune(runes[index]) {
+		index++
+	}
+	return index
+}
+
+func mergePlain(fragments []Fragment) []Fragment {
+	merged := make([]Fragment, 0, len(fragments))
+	for _, fragment := range fragments {
+		last := len(merged) - 1
+		if last >= 0 && !merged[last].Match && !fragment.Match {
+			merged[last].Text += fragment.Text
+			continue
+		}
+		merged = append(merged, fragment)
+	}
+	return merged
+}
@@ -0,0 +1,140 @@
+package text
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	English = "en"
+	Serbian = "sr"
+
+	minDetectableLetters = 12
+	minDetectionMargin   = 0.02
+)
+
+var englishCorpus = `the apartment was clean and the host was very friendly and helpful
+we had a great stay and would definitely come back again
+the location is perfect close to the beach and the city center
+everything was new and the room was spacious and comfortable
+the bed was comfortable but the bathroom was a bit small
+check in was easy and the host gave us useful recommendations
+the kitchen had everything we needed for cooking
+there was free parking in front of the building
+the wifi was fast and worked without any problems
+it was noisy at night because of the street and the bars nearby
+the view from the balcony was amazing especially at sunset
+the place was not as described in the photos and it was dirty
+the air conditioning did not work and it was very hot
+great value for money highly recommended
+the neighborhood is quiet and safe with shops and restaurants around
+the host responded quickly to all of our questions
+the towels and sheets were fresh and the apartment smelled nice
+we would like to thank the host for the warm welcome
+the guest left the apartment clean and respected the house rules
+communication with the guest was easy and pleasant
+the stairs were steep and there was no elevator in the building
+breakfast was delicious and the staff were kind
+the heating was good and the windows kept the noise out
+it is a lovely little house with a garden and a barbecue
+we enjoyed our holiday and the children loved the pool`
+
+var serbianCorpus = `apartman je bio čist i domaćin je bio veoma ljubazan i uslužan
+imali smo odličan boravak i sigurno ćemo se ponovo vratiti
+lokacija je savršena blizu plaže i centra grada
+sve je bilo novo a soba je bila prostrana i udobna
+krevet je bio udoban ali je kupatilo bilo malo
+prijava je bila laka i domaćin nam je dao korisne preporuke
+u kuhinji je bilo sve što nam je trebalo za kuvanje
+ispred zgrade postoji besplatan parking
+internet je bio brz i radio je bez ikakvih problema
+noću je bilo bučno zbog ulice i kafića u blizini
+pogled sa terase je bio neverovatan naročito u zalazak sunca
+smeštaj nije bio kao na slikama i bio je prljav
+klima uređaj nije radio i bilo je jako toplo
+odličan odnos cene i kvaliteta toplo preporučujem
+kraj je miran i bezbedan sa prodavnicama i restoranima u okolini
+domaćin je brzo odgovarao na sva naša pitanja
+peškiri i posteljina su bili sveži a apartman je lepo mirisao
+želimo da se zahvalimo domaćinu na toplom dočeku
+gost je ostavio apartman čistim i poštovao je kućni red
+komunikacija sa gostom je bila laka i prijatna
+stepenice su bile strme i u zgradi nema lifta
+doručak je bio ukusan a osoblje ljubazno
+grejanje je bilo dobro a prozori nisu propuštali buku
+to je divna mala kuća sa baštom i roštiljem
+uživali smo na odmoru a deca su obožavala bazen
+sve pohvale za smeštaj i za domaćina`
+
+type profile map[string]float64
+
+var languageProfiles = map[string]profile{
+	English: buildProfile(englishCorpus),
+	Serbian: buildProfile(serbianCorpus),
+}
+
+// DetectLanguage returns the ISO 639-1 code of the most likely language of
+// the text, or an empty string when the text is too short or ambiguous.
+func DetectLanguage(value string) string {
+	if HasCyrillic(value) {
+		return Serbian
+	}
+
+	letters := 0
+	for _, r := range value {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if letters < minDetectableLetters {
+		return ""
+	}
+
+	candidate := buildProfile(value)
+	best, bestScore, secondScore := "", -1.0, -1.0
+	for language, languageProfile := range languageProfiles {
+		score := cosine(candidate, languageProfile)
+		if score > bestScore {
+			best, bestScore, secondScore = language, score, bestScore
+		} else if score > secondScore {
+			secondScore = score
+		}
+	}
+	if bestScore-secondScore < minDetectionMargin {
+		return ""
+	}
+	return best
+}
+
+func buildProfile(value string) profile {
+	counts := make(profile)
+	for _, word := range Tokenize(value) {
+		runes := []rune(" " + word + " ")
+		for n := 1; n <= 3; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				gram := string(runes[i : i+n])
+				if strings.TrimSpace(gram) == "" {
+					continue
+				}
+				counts[gram]++
+			}
+		}
+	}
+	return counts
+}
+
+func cosine(a profile, b profile) float64 {
+	var dot, normA, normB float64
+	for gram, weight := range a {
+		dot += weight * b[gram]
+		normA += weight * weight
+	}
+	for _, weight := range b {
+		normB += weight * weight
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
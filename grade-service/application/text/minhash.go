@@ -0,0 +1,79 @@
+package text
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+)
+
+const (
+	ShingleSize      = 3
+	MinHashFunctions = 64
+)
+
+// Signature is a MinHash signature; the share of equal positions in two
+// signatures estimates the Jaccard similarity of their shingle sets.
+type Signature []uint64
+
+// Shingles returns the distinct word n-grams of a text. Texts shorter than
+// size words yield a single shingle of all their words.
+func Shingles(value string, size int) []string {
+	words := Tokenize(value)
+	if len(words) == 0 {
+		return nil
+	}
+	if len(words) < size {
+		return []string{strings.Join(words, " ")}
+	}
+
+	seen := make(map[string]struct{})
+	shingles := make([]string, 0, len(words)-size+1)
+	for i := 0; i+size <= len(words); i++ {
+		shingle := strings.Join(words[i:i+size], " ")
+		if _, ok := seen[shingle]; ok {
+			continue
+		}
+		seen[shingle] = struct{}{}
+		shingles = append(shingles, shingle)
+	}
+	return shingles
+}
+
+func MinHash(shingles []string, functions int) Signature {
+	signature := make(Signature, functions)
+	for i := range signature {
+		signature[i] = math.MaxUint64
+	}
+	for _, shingle := range shingles {
+		hasher := fnv.New64a()
+		_, _ = hasher.Write([]byte(shingle))
+		base := hasher.Sum64()
+		for i := range signature {
+			if value := mix(base, uint64(i)); value < signature[i] {
+				signature[i] = value
+			}
+		}
+	}
+	return signature
+}
+
+func (signature Signature) Similarity(other Signature) float64 {
+	if len(signature) == 0 || len(signature) != len(other) {
+		return 0
+	}
+	equal := 0
+	for i := range signature {
+		if signature[i] == other[i] && signature[i] != math.MaxUint64 {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(signature))
+}
+
+// mix derives the seed-th hash function from one base hash (splitmix64).
+func mix(base uint64, seed uint64) uint64 {
+	value := base + (seed+1)*0x9e3779b97f4a7c15
+	value = (value ^ (value >> 30)) * 0xbf58476d1ce4e5b9
+	value = (value ^ (value >> 27)) * 0x94d049bb133111eb
+	return value ^ (value >> 31)
+}
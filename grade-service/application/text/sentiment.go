@@ -0,0 +1,121 @@
+package text
+
+import (
+	"math"
+	"strings"
+)
+
+const (
+	negationWindow    = 3
+	negationFactor    = -0.74
+	intensifierFactor = 1.5
+	dampenerFactor    = 0.5
+	// normalizationAlpha bounds the summed valence to (-1, 1); larger values
+	// need more opinion words to reach the extremes.
+	normalizationAlpha = 15
+)
+
+// Lexicon entries are normalized words with a valence between -3 and 3.
+// Entries ending in "*" match any word with that prefix, which covers
+// Serbian inflection (odličan, odlična, odlično...). Entries and the scored
+// text are both folded to ASCII, so reviews written without diacritics
+// ("odlicno", "cisto") score the same.
+var englishSentiment = map[string]float64{
+	"amazing": 3, "awesome": 3, "beautiful": 2.5, "best": 3, "clean": 2, "comfortable": 2, "comfy": 2,
+	"cozy": 2, "delicious": 2.5, "enjoyed": 2, "excellent": 3, "fantastic": 3, "friendly": 2, "good": 1.5,
+	"great": 2.5, "helpful": 2, "hospitable": 2, "lovely": 2.5, "nice": 1.5, "perfect": 3, "pleasant": 2,
+	"quiet": 1, "recommend": 2, "recommended": 2, "responsive": 1.5, "spacious": 1.5, "spotless": 2.5,
+	"stunning": 3, "super": 2, "welcoming": 2, "wonderful": 3, "love": 2.5, "loved": 2.5, "happy": 2,
+	"bad": -2, "broken": -2, "cold": -1, "cramped": -1.5, "dirty": -2.5, "disappointed": -2.5,
+	"disappointing": -2.5, "disgusting": -3, "filthy": -3, "horrible": -3, "awful": -3, "mold": -2,
+	"moldy": -2.5, "noisy": -1.5, "poor": -2, "rude": -2.5, "scam": -3, "smelly": -2, "smelled": -1,
+	"terrible": -3, "uncomfortable": -2, "unfriendly": -2, "unpleasant": -2, "worst": -3, "overpriced": -2,
+	"bugs": -2, "cockroaches": -3, "avoid": -2.5, "hate": -2.5, "problem": -1, "problems": -1.5,
+}
+
+var serbianSentiment = map[string]float64{
+	"odlič*": 3, "sjajn*": 3, "savršen*": 3, "fantastič*": 3, "divn*": 2.5, "prelep*": 2.5, "lep*": 2,
+	"dobar": 1.5, "dobra": 1.5, "dobro": 1.5, "čist*": 2, "udob*": 2, "ljubaz*": 2, "prijat*": 2,
+	"preporuč*": 2, "pohval*": 2, "uslužn*": 2, "gostoprim*": 2, "ukusn*": 2.5, "miran": 1, "mirn*": 1,
+	"prostran*": 1.5, "zadovolj*": 2, "uživ*": 2, "obožav*": 2.5, "najbolj*": 3, "super": 2,
+	"loš*": -2, "prljav*": -2.5, "bučn*": -1.5, "neudob*": -2, "neljubaz*": -2.5, "užas*": -3,
+	"grozn*": -3, "razočar*": -2.5, "pokvar*": -2, "neprijat*": -2, "smrd*": -2.5, "buđ*": -2,
+	"najgor*": -3, "skup*": -1, "prevar*": -3, "bubašvab*": -3, "problem*": -1.5, "katastrof*": -3,
+}
+
+var negations = buildStopwords(foldWords([]string{
+	"not", "no", "never", "nothing", "nobody", "without", "hardly", "isn", "wasn", "didn", "don", "doesn",
+	"ne", "nije", "nisu", "nismo", "nisam", "nema", "nimalo", "bez", "nikad", "nikada",
+}))
+
+var intensifiers = buildStopwords(foldWords([]string{
+	"very", "extremely", "really", "so", "super", "totally", "absolutely", "incredibly", "truly", "highly",
+	"veoma", "jako", "vrlo", "baš", "izuzetno", "mnogo", "stvarno", "zaista", "potpuno",
+}))
+
+var dampeners = buildStopwords(foldWords([]string{
+	"slightly", "somewhat", "bit", "little", "fairly", "pomalo", "donekle", "prilično",
+}))
+
+var sentimentLexicon, sentimentStems = buildLexicon(englishSentiment, serbianSentiment)
+
+// Sentiment scores the text between -1 (negative) and 1 (positive). Valences
+// are flipped after a nearby negation and scaled by a preceding intensifier
+// or dampener, so "not very clean" scores below zero.
+func Sentiment(value string) float64 {
+	words := foldWords(Tokenize(value))
+	var total float64
+	for i, word := range words {
+		valence, ok := lookupValence(word)
+		if !ok {
+			continue
+		}
+		if i > 0 {
+			if _, ok := intensifiers[words[i-1]]; ok {
+				valence *= intensifierFactor
+			} else if _, ok := dampeners[words[i-1]]; ok {
+				valence *= dampenerFactor
+			}
+		}
+		for j := max(0, i-negationWindow); j < i; j++ {
+			if _, ok := negations[words[j]]; ok {
+				valence *= negationFactor
+				break
+			}
+		}
+		total += valence
+	}
+	return total / math.Sqrt(total*total+normalizationAlpha)
+}
+
+func lookupValence(word string) (float64, bool) {
+	if valence, ok := sentimentLexicon[word]; ok {
+		return valence, true
+	}
+	match := ""
+	for stem := range sentimentStems {
+		if strings.HasPrefix(word, stem) && len(stem) > len(match) {
+			match = stem
+		}
+	}
+	if match == "" {
+		return 0, false
+	}
+	return sentimentStems[match], true
+}
+
+func buildLexicon(lexicons ...map[string]float64) (map[string]float64, map[string]float64) {
+	words := make(map[string]float64)
+	stems := make(map[string]float64)
+	for _, lexicon := range lexicons {
+		for entry, valence := range lexicon {
+			entry = FoldDiacritics(entry)
+			if stem, ok := strings.CutSuffix(entry, "*"); ok {
+				stems[stem] = valence
+				continue
+			}
+			words[entry] = valence
+		}
+	}
+	return words, stems
+}
@@ -0,0 +1,44 @@
+package text
+
+// Stopwords are stored normalized, so Serbian entries are in Latin script.
+var englishStopwords = []string{
+	"a", "about", "above", "after", "again", "against", "all", "also", "am", "an", "and", "any", "are", "as", "at",
+	"be", "because", "been", "before", "being", "below", "between", "both", "but", "by",
+	"can", "could", "did", "do", "does", "doing", "down", "during", "each", "even", "ever", "every",
+	"few", "for", "from", "further", "get", "got", "had", "has", "have", "having", "he", "her", "here", "hers",
+	"herself", "him", "himself", "his", "how", "i", "if", "in", "into", "is", "it", "its", "itself", "just",
+	"let", "me", "more", "most", "much", "my", "myself", "no", "nor", "not", "now", "of", "off", "on", "once",
+	"only", "or", "other", "our", "ours", "ourselves", "out", "over", "own", "really", "same", "she", "should",
+	"so", "some", "still", "such", "than", "that", "the", "their", "theirs", "them", "themselves", "then",
+	"there", "these", "they", "this", "those", "through", "to", "too", "under", "until", "up", "us", "very",
+	"was", "we", "were", "what", "when", "where", "which", "while", "who", "whom", "why", "will", "with",
+	"would", "you", "your", "yours", "yourself", "yourselves",
+}
+
+var serbianStopwords = []string{
+	"a", "ako", "ali", "bi", "bih", "bila", "bile", "bili", "bilo", "bio", "bismo", "biste", "bude", "budu",
+	"da", "do", "dok", "ga", "gde", "i", "ih", "ili", "im", "ja", "je", "jer", "jesu", "joj", "još", "ju",
+	"kad", "kada", "kako", "kao", "koja", "koje", "koji", "kojima", "koju", "li", "me", "mene", "mi", "moj",
+	"moja", "moje", "mu", "na", "nad", "nam", "nas", "naš", "naša", "naše", "ne", "nego", "neki", "nešto",
+	"ni", "nije", "nisam", "nismo", "niste", "nisu", "nju", "njega", "njegov", "njemu", "njen", "njih", "njoj",
+	"o", "od", "on", "ona", "one", "oni", "ono", "pa", "po", "pod", "pre", "pri", "sa", "sam", "samo", "se",
+	"si", "sme", "smo", "ste", "su", "sve", "svi", "svoj", "ta", "tako", "te", "ti", "to", "toga", "tu",
+	"u", "uz", "va", "vam", "vas", "vaš", "već", "vi", "za", "zbog", "što", "šta", "će", "ćemo", "ću",
+}
+
+var stopwords = buildStopwords(englishStopwords, serbianStopwords)
+
+func IsStopword(word string) bool {
+	_, ok := stopwords[word]
+	return ok
+}
+
+func buildStopwords(lists ...[]string) map[string]struct{} {
+	words := make(map[string]struct{})
+	for _, list := range lists {
+		for _, word := range list {
+			words[word] = struct{}{}
+		}
+	}
+	return words
+}
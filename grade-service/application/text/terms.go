@@ -0,0 +1,43 @@
+package text
+
+import "unicode/utf8"
+
+const minTermLength = 3
+
+// Terms returns the distinct keywords and bigrams of a text. Stopwords and
+// very short words are dropped and break bigrams, so "close to the beach"
+// yields "close" and "beach" but no bigram.
+func Terms(value string) []string {
+	seen := make(map[string]struct{})
+	var terms []string
+	add := func(term string) {
+		if _, ok := seen[term]; ok {
+			return
+		}
+		seen[term] = struct{}{}
+		terms = append(terms, term)
+	}
+
+	previous := ""
+	for _, word := range Tokenize(value) {
+		if IsStopword(word) || utf8.RuneCountInString(word) < minTermLength || isNumber(word) {
+			previous = ""
+			continue
+		}
+		add(word)
+		if previous != "" {
+			add(previous + " " + word)
+		}
+		previous = word
+	}
+	return terms
+}
+
+func isNumber(word string) bool {
+	for _, r := range word {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+)
+
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'ђ': "đ", 'е': "e", 'ж': "ž", 'з': "z",
+	'и': "i", 'ј': "j", 'к': "k", 'л': "l", 'љ': "lj", 'м': "m", 'н': "n", 'њ': "nj", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'ћ': "ć", 'у': "u", 'ф': "f", 'х': "h", 'ц': "c",
+	'ч': "č", 'џ': "dž", 'ш': "š",
+}
+
+// Normalize lowercases the text and transliterates Serbian Cyrillic to Latin
+// so both scripts share one vocabulary.
+func Normalize(value string) string {
+	var builder strings.Builder
+	builder.Grow(len(value))
+	for _, r := range strings.ToLower(value) {
+		if latin, ok := cyrillicToLatin[r]; ok {
+			builder.WriteString(latin)
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+var asciiFolding = strings.NewReplacer("č", "c", "ć", "c", "š", "s", "ž", "z", "đ", "dj")
+
+// FoldDiacritics replaces the Serbian Latin letters with diacritics in
+// normalized text by their usual ASCII spelling.
+func FoldDiacritics(value string) string {
+	return asciiFolding.Replace(value)
+}
+
+func foldWords(words []string) []string {
+	folded := make([]string, len(words))
+	for i, word := range words {
+		folded[i] = FoldDiacritics(word)
+	}
+	return folded
+}
+
+// Tokenize splits normalized text into words made of letters and digits.
+func Tokenize(value string) []string {
+	return strings.FieldsFunc(Normalize(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func HasCyrillic(value string) bool {
+	for _, r := range value {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,9 @@
+package domain
+
+import "io"
+
+type BlobStore interface {
+	Put(key string, contentType string, content io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
@@ -1,15 +1,23 @@
 package domain
 
 const (
-	GradeContextPath      string = "/grade"
-	BearerSchema          string = "Bearer "
-	Authorization         string = "Authorization"
-	ContentType           string = "Content-Type"
-	JsonContentType       string = "application/json"
-	HealthCheckMessage    string = "GRADE SERVICE IS HEALTH"
-	InvalidIDErrorMessage string = "Invalid review ID"
-	ServiceName           string = "grade-service"
-	ADD                   int    = 0
-	SUB                   int    = 1
-	UPDATE                int    = 2
+	GradeContextPath         string = "/grade"
+	GradeV1ContextPath       string = GradeContextPath + "/v1"
+	GradeV2ContextPath       string = GradeContextPath + "/v2"
+	BearerSchema             string = "Bearer "
+	Authorization            string = "Authorization"
+	JwtPayloadHeader         string = "x-jwt-payload"
+	IdempotencyKeyHeader     string = "Idempotency-Key"
+	IdempotentReplayedHeader string = "Idempotent-Replayed"
+	GuestRole                string = "guest"
+	HostRole                 string = "host"
+	AdminRole                string = "admin"
+	ContentType              string = "Content-Type"
+	JsonContentType          string = "application/json"
+	HealthCheckMessage       string = "GRADE SERVICE IS HEALTH"
+	InvalidIDErrorMessage    string = "Invalid review ID"
+	ServiceName              string = "grade-service"
+	ADD                      int    = 0
+	SUB                      int    = 1
+	UPDATE                   int    = 2
 )
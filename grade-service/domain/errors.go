@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorKind classifies errors by what went wrong from the caller's point of
+// view; the API maps each kind to one HTTP status.
+type ErrorKind string
+
+const (
+	KindValidation    ErrorKind = "validation"
+	KindUnauthorized  ErrorKind = "unauthorized"
+	KindForbidden     ErrorKind = "forbidden"
+	KindNotFound      ErrorKind = "not_found"
+	KindConflict      ErrorKind = "conflict"
+	KindUnprocessable ErrorKind = "unprocessable"
+	KindPrecondition  ErrorKind = "precondition_failed"
+	KindTooLarge      ErrorKind = "too_large"
+	KindUnsupported   ErrorKind = "unsupported"
+	KindRateLimited   ErrorKind = "rate_limited"
+	KindUpstream      ErrorKind = "upstream"
+	KindInternal      ErrorKind = "internal"
+)
+
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// Error carries a stable machine readable Code next to the message shown to
+// clients. Err keeps the underlying cause for logs and errors.Is.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (err *Error) Error() string {
+	if err.Err != nil {
+		return err.Message + ": " + err.Err.Error()
+	}
+	return err.Message
+}
+
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+func NewError(kind ErrorKind, code string, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NewValidationError(code string, message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Fields: fields}
+}
+
+func NewValidationErrorf(code string, format string, args ...interface{}) *Error {
+	return NewValidationError(code, fmt.Sprintf(format, args...))
+}
+
+func NewUnauthorizedError(code string, message string, err error) *Error {
+	return &Error{Kind: KindUnauthorized, Code: code, Message: message, Err: err}
+}
+
+func NewForbiddenError(code string, message string) *Error {
+	return NewError(KindForbidden, code, message)
+}
+
+func NewNotFoundError(code string, message string) *Error {
+	return NewError(KindNotFound, code, message)
+}
+
+func NewUpstreamError(code string, message string, err error) *Error {
+	return &Error{Kind: KindUpstream, Code: code, Message: message, Err: err}
+}
+
+// AsError returns the classified error in err's chain, or an internal error
+// wrapping err when there is none.
+func AsError(err error) *Error {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr
+	}
+	return &Error{Kind: KindInternal, Code: "internal_error", Message: "Internal server error", Err: err}
+}
+
+var (
+	ErrOwnReviewVote              = NewForbiddenError("own_review_vote", "authors can not vote on their own reviews")
+	ErrAlreadyReported            = NewError(KindConflict, "already_reported", "review has already been reported by this user")
+	ErrNotReviewAuthor            = NewForbiddenError("not_review_author", "only the author can change this review")
+	ErrReservationRequired        = NewForbiddenError("reservation_required", "reviewer has no reservation with the reviewed subject")
+	ErrTooManyAttachments         = NewError(KindConflict, "too_many_attachments", "review has reached the maximum number of attachments")
+	ErrAttachmentTooLarge         = NewError(KindTooLarge, "attachment_too_large", "attachment exceeds the maximum size")
+	ErrUnsupportedAttachmentType  = NewError(KindUnsupported, "unsupported_attachment_type", "attachment type is not supported")
+	ErrInvalidAttachmentSignature = NewForbiddenError("invalid_attachment_signature", "attachment link is invalid or has expired")
+	ErrNotFound                   = NewNotFoundError("not_found", "resource not found")
+	ErrReviewNotFound             = NewNotFoundError("review_not_found", "review not found")
+	ErrAttachmentNotFound         = NewNotFoundError("attachment_not_found", "attachment not found")
+	ErrRateLimited                = NewError(KindRateLimited, "rate_limited", "too many requests")
+	ErrVersionMismatch            = NewError(KindPrecondition, "version_mismatch", "review has changed since it was read")
+	ErrReviewTypeMismatch         = NewError(KindConflict, "review_type_mismatch", "review type does not match the stored review")
+	ErrIdempotencyKeyReused       = NewError(KindUnprocessable, "idempotency_key_reused", "idempotency key was already used for a different request")
+	ErrRequestInProgress          = NewError(KindConflict, "request_in_progress", "a request with this idempotency key is still being processed")
+	ErrTranslationUnavailable     = NewError(KindUpstream, "translation_unavailable", "no translation backend is configured")
+)
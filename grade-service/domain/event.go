@@ -0,0 +1,23 @@
+package domain
+
+const (
+	RatingChangedEventType = "com.zms.grade.rating.changed"
+	ReviewCreatedEventType = "com.zms.grade.review.created"
+
+	// Data schemas are versioned separately from the event type; a breaking
+	// change to a payload gets a new schema version.
+	RatingChangedSchema = "urn:zms:grade:rating-changed:v1"
+	ReviewCreatedSchema = "urn:zms:grade:review-created:v1"
+)
+
+// Event is published as a CloudEvents 1.0 structured event. Key orders the
+// events on a topic: events with the same key are delivered in order, so it
+// holds the reviewed subject.
+type Event struct {
+	Topic      string
+	Key        string
+	Type       string
+	Subject    string
+	DataSchema string
+	Data       interface{}
+}
@@ -0,0 +1,9 @@
+package domain
+
+import "context"
+
+// EventPublisher delivers events to the services that listen for review
+// changes. The context carries the trace the event belongs to.
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// IdempotentRequest remembers a write request under its Idempotency-Key. A
+// nil Response means the first request with the key is still being handled,
+// until ExpiresAt ends its lease.
+type IdempotentRequest struct {
+	Key         string              `bson:"_id"`
+	RequestHash string              `bson:"request_hash"`
+	Response    *IdempotentResponse `bson:"response,omitempty"`
+	ExpiresAt   time.Time           `bson:"expires_at"`
+}
+
+type IdempotentResponse struct {
+	StatusCode  int    `bson:"status_code"`
+	ContentType string `bson:"content_type"`
+	ETag        string `bson:"etag,omitempty"`
+	Body        []byte `bson:"body"`
+}
+
+type IdempotencyStore interface {
+	// Reserve stores the request unless an unexpired one with the same key is
+	// stored, in which case it returns the stored request instead.
+	Reserve(request *IdempotentRequest) (*IdempotentRequest, error)
+	// Complete stores the response and keeps the request until expiresAt.
+	Complete(key string, response IdempotentResponse, expiresAt time.Time) error
+	// Release forgets the request, so a retry is handled again.
+	Release(key string) error
+}
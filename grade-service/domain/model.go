@@ -10,6 +10,7 @@ type ReviewType int
 const (
 	Host ReviewType = iota
 	Accommodation
+	Guest
 )
 
 type Review struct {
@@ -21,4 +22,174 @@ type Review struct {
 	ReviewerFullName   string             `bson:"reviewer_full_name"`
 	DateOfModification time.Time          `bson:"date_of_modification"`
 	Type               ReviewType         `bson:"type"`
+	HelpfulCount       int                `bson:"helpful_count"`
+	NotHelpfulCount    int                `bson:"not_helpful_count"`
+	Status             ReviewStatus       `bson:"status"`
+	Attachments        []Attachment       `bson:"attachments"`
+	Language           string             `bson:"language"`
+	Sentiment          Sentiment          `bson:"sentiment"`
+	// ReviewerCreatedAt is when the reviewer's account was created, taken
+	// from their token. It is zero when the token does not carry it.
+	ReviewerCreatedAt time.Time `bson:"reviewer_created_at,omitempty"`
+	// Version counts the changes to the stored review and backs its ETag.
+	// Reviews stored before versioning have version 0.
+	Version int64 `bson:"version"`
+	// Flagged reviews are part of a fake review finding and wait for a
+	// moderator to look at them. They stay published unless auto-hiding is on.
+	Flagged bool `bson:"flagged"`
+}
+
+// Sentiment is scored from the comment when the review is written. Mismatch
+// marks reviews whose text strongly contradicts the grade, such as a glowing
+// comment left with one star.
+type Sentiment struct {
+	Score    float64 `bson:"score"`
+	Mismatch bool    `bson:"mismatch"`
+}
+
+type SentimentLabel string
+
+const (
+	SentimentPositive SentimentLabel = "positive"
+	SentimentNeutral  SentimentLabel = "neutral"
+	SentimentNegative SentimentLabel = "negative"
+
+	sentimentThreshold = 0.2
+)
+
+func (sentiment Sentiment) Label() SentimentLabel {
+	switch {
+	case sentiment.Score >= sentimentThreshold:
+		return SentimentPositive
+	case sentiment.Score <= -sentimentThreshold:
+		return SentimentNegative
+	default:
+		return SentimentNeutral
+	}
+}
+
+type Attachment struct {
+	Id             string    `bson:"id"`
+	FileName       string    `bson:"file_name"`
+	ContentType    string    `bson:"content_type"`
+	Size           int64     `bson:"size"`
+	DateOfCreation time.Time `bson:"date_of_creation"`
+}
+
+// ReviewStatus is empty for reviews stored before moderation existed; those
+// are treated as published.
+type ReviewStatus string
+
+const (
+	StatusPublished         ReviewStatus = "published"
+	StatusPendingModeration ReviewStatus = "pending_moderation"
+)
+
+type ReviewVote struct {
+	Id                 primitive.ObjectID `bson:"_id"`
+	ReviewId           primitive.ObjectID `bson:"review_id"`
+	Voter              string             `bson:"voter"`
+	Helpful            bool               `bson:"helpful"`
+	DateOfModification time.Time          `bson:"date_of_modification"`
+}
+
+type ReviewSearchResult struct {
+	Review *Review
+	Score  float64
+}
+
+type ReviewSortOrder string
+
+const (
+	SortUnordered   ReviewSortOrder = ""
+	SortNewest      ReviewSortOrder = "newest"
+	SortMostHelpful ReviewSortOrder = "most-helpful"
+)
+
+type ReportReason string
+
+const (
+	ReasonSpam       ReportReason = "spam"
+	ReasonFake       ReportReason = "fake"
+	ReasonOffensive  ReportReason = "offensive"
+	ReasonIrrelevant ReportReason = "irrelevant"
+	ReasonOther      ReportReason = "other"
+)
+
+type ReviewAbuseReport struct {
+	Id             primitive.ObjectID `bson:"_id"`
+	ReviewId       primitive.ObjectID `bson:"review_id"`
+	Reporter       string             `bson:"reporter"`
+	Reason         ReportReason       `bson:"reason"`
+	Text           string             `bson:"text"`
+	DateOfCreation time.Time          `bson:"date_of_creation"`
+	// Resolved reports were handled by a moderator and no longer count.
+	Resolved bool `bson:"resolved"`
+}
+
+type ReasonCount struct {
+	Reason ReportReason `bson:"reason"`
+	Count  int          `bson:"count"`
+}
+
+type ReportedReview struct {
+	ReviewId       primitive.ObjectID `bson:"_id"`
+	TotalReports   int                `bson:"total_reports"`
+	Reasons        []ReasonCount      `bson:"reasons"`
+	LastReportedAt time.Time          `bson:"last_reported_at"`
+}
+
+type ReviewSubject struct {
+	SubReviewed string     `bson:"sub_reviewed"`
+	Type        ReviewType `bson:"type"`
+}
+
+type TermStats struct {
+	Term         string  `bson:"term"`
+	Mentions     int     `bson:"mentions"`
+	AverageGrade float32 `bson:"average_grade"`
+}
+
+type ReviewSummary struct {
+	SubReviewed        string      `bson:"sub_reviewed"`
+	Type               ReviewType  `bson:"type"`
+	Terms              []TermStats `bson:"terms"`
+	TopPositive        []TermStats `bson:"top_positive"`
+	TopNegative        []TermStats `bson:"top_negative"`
+	DateOfModification time.Time   `bson:"date_of_modification"`
+}
+
+type SuspicionKind string
+
+const (
+	SuspicionReviewBurst     SuspicionKind = "review_burst"
+	SuspicionExtremeReviewer SuspicionKind = "extreme_reviewer"
+	SuspicionNearDuplicate   SuspicionKind = "near_duplicate"
+	SuspicionFreshAccounts   SuspicionKind = "fresh_accounts"
+)
+
+// Suspicion is a finding of the fake review detector. Key identifies the
+// finding across detection runs, so a repeated finding updates the stored
+// one instead of adding another.
+type Suspicion struct {
+	Id             primitive.ObjectID   `bson:"_id"`
+	Key            string               `bson:"key"`
+	Kind           SuspicionKind        `bson:"kind"`
+	SubReviewed    string               `bson:"sub_reviewed"`
+	Type           ReviewType           `bson:"type"`
+	Reviewer       string               `bson:"reviewer,omitempty"`
+	ReviewIds      []primitive.ObjectID `bson:"review_ids"`
+	Score          float64              `bson:"score"`
+	Details        string               `bson:"details"`
+	DateOfCreation time.Time            `bson:"date_of_creation"`
+	LastDetectedAt time.Time            `bson:"last_detected_at"`
+}
+
+// RatingVersion counts the rating changes of a subject. Every published
+// rating carries the version it was computed at.
+type RatingVersion struct {
+	SubReviewed        string     `bson:"sub_reviewed"`
+	Type               ReviewType `bson:"type"`
+	Version            int64      `bson:"version"`
+	DateOfModification time.Time  `bson:"date_of_modification"`
 }
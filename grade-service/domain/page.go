@@ -0,0 +1,33 @@
+package domain
+
+// Page selects a slice of a listing. Numbers start at 1; a zero Size selects
+// the whole listing.
+type Page struct {
+	Number int
+	Size   int
+}
+
+// Bounds returns the range of a listing of total items that falls on the
+// page, which is empty past the last page.
+func (page Page) Bounds(total int) (int, int) {
+	if page.Size == 0 {
+		return 0, total
+	}
+	start := (page.Number - 1) * page.Size
+	if start > total {
+		start = total
+	}
+	end := start + page.Size
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// Count returns how many pages a listing of total items takes.
+func (page Page) Count(total int) int {
+	if page.Size == 0 {
+		return 1
+	}
+	return (total + page.Size - 1) / page.Size
+}
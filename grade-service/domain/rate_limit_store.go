@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// RateLimit allows Requests per Period, refilled continuously, with bursts
+// of up to Requests.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// IsValid reports whether the limit lets any request through; buckets of
+// invalid limits would never fill.
+func (limit RateLimit) IsValid() bool {
+	return limit.Requests > 0 && limit.Period > 0
+}
+
+type RateLimitStore interface {
+	// Take consumes a token from the bucket of the key. When the bucket is
+	// empty it returns false and how long until the next token.
+	Take(key string, limit RateLimit) (bool, time.Duration, error)
+	// Refund puts back a token taken for a request that was rejected anyway.
+	Refund(key string, limit RateLimit) error
+}
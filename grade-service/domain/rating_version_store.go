@@ -0,0 +1,9 @@
+package domain
+
+type RatingVersionStore interface {
+	// Next increments the version of the subject's rating and returns it.
+	Next(subReviewed string, reviewType ReviewType) (int64, error)
+	// Get returns the current version, or 0 if the rating never changed.
+	Get(subReviewed string, reviewType ReviewType) (int64, error)
+	DeleteAll()
+}
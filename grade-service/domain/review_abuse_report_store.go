@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReviewAbuseReportStore interface {
+	// Insert returns ErrAlreadyReported when the reporter has an unresolved
+	// report of the review.
+	Insert(report *ReviewAbuseReport) error
+	// CountByReview and GetReportedReviews leave out resolved reports.
+	CountByReview(reviewId primitive.ObjectID) (int, error)
+	GetReportedReviews() ([]*ReportedReview, error)
+	// Resolve marks every report of the review as resolved.
+	Resolve(reviewId primitive.ObjectID) error
+	DeleteAll()
+}
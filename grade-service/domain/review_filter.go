@@ -0,0 +1,63 @@
+package domain
+
+import "time"
+
+// ReviewFilter narrows a listing of reviews. Zero values match everything.
+type ReviewFilter struct {
+	Stars    []int
+	From     *time.Time
+	To       *time.Time
+	Language string
+}
+
+func (filter ReviewFilter) IsEmpty() bool {
+	return len(filter.Stars) == 0 && filter.From == nil && filter.To == nil && filter.Language == ""
+}
+
+func (filter ReviewFilter) Matches(review *Review) bool {
+	if len(filter.Stars) > 0 && !containsStar(filter.Stars, StarsOf(review.Grade)) {
+		return false
+	}
+	if filter.From != nil && review.DateOfModification.Before(*filter.From) {
+		return false
+	}
+	if filter.To != nil && review.DateOfModification.After(*filter.To) {
+		return false
+	}
+	if filter.Language != "" && review.Language != filter.Language {
+		return false
+	}
+	return true
+}
+
+// StarsOf rounds a grade up to the star bucket it is counted in, so 2.5 is
+// a three star review.
+func StarsOf(grade float32) int {
+	switch {
+	case grade <= 1:
+		return 1
+	case grade <= 2:
+		return 2
+	case grade <= 3:
+		return 3
+	case grade <= 4:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// StarGradeRange returns the grade interval (min, max] of a star bucket. The
+// one star bucket also includes its lower bound.
+func StarGradeRange(stars int) (float32, float32) {
+	return float32(stars - 1), float32(stars)
+}
+
+func containsStar(stars []int, star int) bool {
+	for _, candidate := range stars {
+		if candidate == star {
+			return true
+		}
+	}
+	return false
+}
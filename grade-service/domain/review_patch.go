@@ -0,0 +1,15 @@
+package domain
+
+// ReviewPatch lists the review fields a partial update changes. Nil fields
+// keep their stored value. DetectLanguage drops the stored language in favour
+// of the one detected from the comment.
+type ReviewPatch struct {
+	Comment        *string
+	Grade          *float32
+	Language       *string
+	DetectLanguage bool
+}
+
+func (patch ReviewPatch) IsEmpty() bool {
+	return patch.Comment == nil && patch.Grade == nil && patch.Language == nil && !patch.DetectLanguage
+}
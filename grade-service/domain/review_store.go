@@ -4,11 +4,36 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ReviewStore methods that address a single review return ErrReviewNotFound
+// when it does not exist. Every change to the content of a review, its
+// comment, grade or attachments, increments its Version. Votes and moderation
+// do not, so they never fail an edit made against the version the author saw.
 type ReviewStore interface {
 	Get(id primitive.ObjectID) (*Review, error)
-	GetAllBySubReviewed(subReviewed string, reviewType int) ([]*Review, error)
+	GetAllBySubReviewed(subReviewed string, reviewType ReviewType) ([]*Review, error)
+	// GetMatchingBySubReviewed returns the published reviews of a subject that match the filter.
+	GetMatchingBySubReviewed(subReviewed string, reviewType ReviewType, filter ReviewFilter) ([]*Review, error)
+	// Insert assigns a new id unless the review already has one, which keeps
+	// the creation time of imported reviews.
 	Insert(review *Review) (primitive.ObjectID, error)
 	Delete(id primitive.ObjectID) error
 	DeleteAll()
-	Update(id primitive.ObjectID, comment string, grade float32) (*Review, error)
+	// Update returns ErrVersionMismatch when versions is not nil and the
+	// stored version is not one of them. A nil versions updates any version.
+	Update(id primitive.ObjectID, comment string, grade float32, language string, sentiment Sentiment, versions []int64) (*Review, error)
+	// SetVoteCounts overwrites the vote counts with ones counted from the votes.
+	SetVoteCounts(id primitive.ObjectID, helpful int, notHelpful int) (*Review, error)
+	UpdateStatus(id primitive.ObjectID, status ReviewStatus) (*Review, error)
+	// Flag marks the review as part of a fake review finding.
+	Flag(id primitive.ObjectID) (*Review, error)
+	// AddAttachment returns ErrTooManyAttachments when the review already has maxCount attachments.
+	AddAttachment(id primitive.ObjectID, attachment Attachment, maxCount int) (*Review, error)
+	// GetByAttachment returns ErrAttachmentNotFound when no review has the attachment.
+	GetByAttachment(attachmentId string) (*Review, error)
+	// Search returns at most limit published reviews of a subject whose comment
+	// matches the query, ordered by relevance, and how many match in total.
+	Search(subReviewed string, reviewType ReviewType, query string, filter ReviewFilter, limit int) ([]*ReviewSearchResult, int, error)
+	GetSubjects() ([]ReviewSubject, error)
+	// GetAllBySubReviewers returns every review written by the users, hidden ones included.
+	GetAllBySubReviewers(subReviewers []string) ([]*Review, error)
 }
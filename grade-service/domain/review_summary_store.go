@@ -0,0 +1,10 @@
+package domain
+
+type ReviewSummaryStore interface {
+	Get(subReviewed string, reviewType ReviewType) (*ReviewSummary, error)
+	Upsert(summary *ReviewSummary) error
+	Delete(subReviewed string, reviewType ReviewType) error
+	// GetSubjects returns every subject that has a summary.
+	GetSubjects() ([]ReviewSubject, error)
+	DeleteAll()
+}
@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReviewVoteStore interface {
+	// Upsert stores the vote and returns the vote it replaced, or nil if the
+	// voter had not voted on the review before.
+	Upsert(vote *ReviewVote) (*ReviewVote, error)
+	// Count returns how many votes on the review are helpful and not helpful.
+	Count(reviewId primitive.ObjectID) (helpful int, notHelpful int, err error)
+	DeleteByReview(reviewId primitive.ObjectID) error
+	DeleteAll()
+}
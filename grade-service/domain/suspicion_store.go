@@ -0,0 +1,8 @@
+package domain
+
+type SuspicionStore interface {
+	// Upsert inserts the suspicion or refreshes the stored one with the same key.
+	Upsert(suspicion *Suspicion) error
+	GetAll() ([]*Suspicion, error)
+	DeleteAll()
+}
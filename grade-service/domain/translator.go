@@ -0,0 +1,5 @@
+package domain
+
+type Translator interface {
+	Translate(text string, sourceLanguage string, targetLanguage string) (string, error)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/application"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+)
+
+const (
+	attachmentFormField = "file"
+	multipartOverhead   = 1 << 20
+)
+
+type AttachmentHandler struct {
+	attachmentService *application.AttachmentService
+	traceProvider     *sdktrace.TracerProvider
+	loki              promtail.Client
+}
+
+func NewAttachmentHandler(attachmentService *application.AttachmentService, traceProvider *sdktrace.TracerProvider, loki promtail.Client) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+		traceProvider:     traceProvider,
+		loki:              loki,
+	}
+}
+
+func (handler *AttachmentHandler) Init(router *mux.Router) {
+	// Only attachment ids match, so a subject named "attachments" still
+	// reaches the review listing.
+	router.HandleFunc("/attachments/{attachment-id}", handler.GetAttachment).Methods(http.MethodGet).MatcherFunc(isAttachmentPath)
+	router.HandleFunc("/{id}/attachments", handler.UploadAttachment).Methods(http.MethodPost)
+}
+
+func isAttachmentPath(r *http.Request, match *mux.RouteMatch) bool {
+	return primitive.IsValidObjectID(path.Base(r.URL.Path))
+}
+
+func (handler *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	_, span := handler.traceProvider.Tracer(domain.ServiceName).Start(r.Context(), "upload-attachment-post")
+	defer func() { span.End() }()
+	reviewPrimitiveId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		util.HttpTraceError(err, "invalid review id", span, handler.loki, "UploadAttachment", "")
+		handleError(w, span, errInvalidId)
+		return
+	}
+
+	claims, err := getClaims(r)
+	if err != nil {
+		util.HttpTraceError(err, "missing uploader", span, handler.loki, "UploadAttachment", "")
+		handleError(w, span, err)
+		return
+	}
+
+	maxSize := handler.attachmentService.Limits().MaxSize
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartOverhead)
+	file, header, err := r.FormFile(attachmentFormField)
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		util.HttpTraceError(err, "attachment too large", span, handler.loki, "UploadAttachment", "")
+		handleError(w, span, domain.ErrAttachmentTooLarge)
+		return
+	}
+	if err != nil {
+		util.HttpTraceError(err, "invalid attachment payload", span, handler.loki, "UploadAttachment", "")
+		handleError(w, span, domain.NewValidationError("invalid_payload", "Invalid attachment payload"))
+		return
+	}
+	defer file.Close()
+
+	response, err := handler.attachmentService.Upload(reviewPrimitiveId, claims.Subject, header.Filename, file, span, handler.loki)
+	if err != nil {
+		util.HttpTraceError(err, "failed to upload attachment", span, handler.loki, "UploadAttachment", "")
+		handleError(w, span, err)
+		return
+	}
+
+	util.HttpTraceInfo("Attachment uploaded successfully", span, handler.loki, "UploadAttachment", "")
+	writeResponse(w, http.StatusCreated, response)
+}
+
+func (handler *AttachmentHandler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	_, span := handler.traceProvider.Tracer(domain.ServiceName).Start(r.Context(), "get-attachment-get")
+	defer func() { span.End() }()
+	query := r.URL.Query()
+	content, attachment, err := handler.attachmentService.Open(mux.Vars(r)["attachment-id"], query.Get("expires"), query.Get("signature"), span, handler.loki)
+	if err != nil {
+		util.HttpTraceError(err, "failed to open attachment", span, handler.loki, "GetAttachment", "")
+		handleError(w, span, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set(domain.ContentType, attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("error writing attachment: %v", err)
+	}
+}
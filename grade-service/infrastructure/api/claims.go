@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/mmmajder/zms-devops-grade-service/application"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type jwtClaims struct {
+	Subject     string `json:"sub"`
+	RealmAccess struct {
+		Roles []string `json:"roles"`
+	} `json:"realm_access"`
+	// CreatedAt is the Keycloak createdTimestamp of the account in Unix
+	// milliseconds, mapped into the token by a user property mapper.
+	CreatedAt int64 `json:"created_at"`
+}
+
+// getClaims reads the token payload that the Istio RequestAuthentication
+// forwards in the x-jwt-payload header after verifying the signature.
+func getClaims(r *http.Request) (*jwtClaims, error) {
+	payload := r.Header.Get(domain.JwtPayloadHeader)
+	if payload == "" {
+		return nil, domain.NewUnauthorizedError("missing_token", "missing jwt payload", nil)
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(payload, "="))
+	if err != nil {
+		if decoded, err = base64.StdEncoding.DecodeString(payload); err != nil {
+			return nil, domain.NewUnauthorizedError("invalid_token", "invalid jwt payload", err)
+		}
+	}
+
+	var claims jwtClaims
+	if err := json.Unmarshal(decoded, &claims); err != nil {
+		return nil, domain.NewUnauthorizedError("invalid_token", "invalid jwt payload", err)
+	}
+	if claims.Subject == "" {
+		return nil, domain.NewUnauthorizedError("invalid_token", "jwt payload has no subject", nil)
+	}
+	return &claims, nil
+}
+
+func (claims *jwtClaims) hasRole(role string) bool {
+	for _, claimRole := range claims.RealmAccess.Roles {
+		if claimRole == role {
+			return true
+		}
+	}
+	return false
+}
+
+// accountCreatedAt returns when the account was created, or zero when the
+// token does not say.
+func (claims *jwtClaims) accountCreatedAt() time.Time {
+	if claims.CreatedAt <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(claims.CreatedAt)
+}
+
+func authorizeReviewer(r *http.Request, definition application.ReviewTypeDefinition) error {
+	claims, err := getClaims(r)
+	if err != nil {
+		return err
+	}
+
+	if !claims.hasRole(definition.ReviewerRole) {
+		return domain.NewForbiddenError("role_required", fmt.Sprintf("role %s is required to write %s reviews", definition.ReviewerRole, definition.Name))
+	}
+	return nil
+}
+
+func authorizeAdmin(r *http.Request) error {
+	claims, err := getClaims(r)
+	if err != nil {
+		return err
+	}
+
+	if !claims.hasRole(domain.AdminRole) {
+		return domain.NewForbiddenError("role_required", "admin role required")
+	}
+	return nil
+}
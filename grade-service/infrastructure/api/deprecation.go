@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	deprecationHeader = "Deprecation"
+	sunsetHeader      = "Sunset"
+	linkHeader        = "Link"
+)
+
+// Deprecation announces on every response of a deprecated API version since
+// when it is deprecated, when it will be removed and what replaces it. Without
+// a date the version is only marked deprecated, and a zero sunset or an empty
+// successor is left out.
+type Deprecation struct {
+	Date      time.Time
+	Sunset    time.Time
+	Successor string
+}
+
+func (deprecation Deprecation) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if deprecation.Date.IsZero() {
+			w.Header().Set(deprecationHeader, "true")
+		} else {
+			w.Header().Set(deprecationHeader, "@"+strconv.FormatInt(deprecation.Date.Unix(), 10))
+		}
+		if !deprecation.Sunset.IsZero() {
+			w.Header().Set(sunsetHeader, deprecation.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if deprecation.Successor != "" {
+			w.Header().Add(linkHeader, "<"+deprecation.Successor+`>; rel="successor-version"`)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// deprecated tells the client that the request used a deprecated form of the
+// API, which still works but will be removed. A deprecation date set for the
+// whole version is kept.
+func deprecated(w http.ResponseWriter) {
+	if w.Header().Get(deprecationHeader) == "" {
+		w.Header().Set(deprecationHeader, "true")
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	etagHeader    = "ETag"
+	ifMatchHeader = "If-Match"
+)
+
+// etag is a strong validator of the content of the stored review: its
+// version changes with every change to the comment, grade or attachments.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set(etagHeader, etag(version))
+}
+
+// ifMatchVersions returns the versions listed in If-Match, or nil when the
+// header is absent or "*". If-Match compares strongly, so weak tags and tags
+// that are not versions never match; they leave an empty, non-nil list.
+func ifMatchVersions(r *http.Request) []int64 {
+	header := strings.TrimSpace(r.Header.Get(ifMatchHeader))
+	if header == "" || header == "*" {
+		return nil
+	}
+	versions := make([]int64, 0)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+			continue
+		}
+		version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
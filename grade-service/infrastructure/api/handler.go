@@ -2,22 +2,73 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/trace"
 	"log"
 	"net/http"
 )
 
-func handleError(w http.ResponseWriter, httpStatus int, message string) {
-	w.WriteHeader(httpStatus)
-	if _, err := w.Write([]byte(message)); err != nil {
-		log.Printf("error writing response: %v", err)
+var errInvalidId = domain.NewValidationError("invalid_id", domain.InvalidIDErrorMessage)
+
+var errorStatuses = map[domain.ErrorKind]int{
+	domain.KindValidation:    http.StatusBadRequest,
+	domain.KindUnauthorized:  http.StatusUnauthorized,
+	domain.KindForbidden:     http.StatusForbidden,
+	domain.KindNotFound:      http.StatusNotFound,
+	domain.KindConflict:      http.StatusConflict,
+	domain.KindUnprocessable: http.StatusUnprocessableEntity,
+	domain.KindPrecondition:  http.StatusPreconditionFailed,
+	domain.KindTooLarge:      http.StatusRequestEntityTooLarge,
+	domain.KindUnsupported:   http.StatusUnsupportedMediaType,
+	domain.KindRateLimited:   http.StatusTooManyRequests,
+	domain.KindUpstream:      http.StatusBadGateway,
+	domain.KindInternal:      http.StatusInternalServerError,
+}
+
+// ErrorStatus maps err to its domain error and the HTTP status of its kind.
+// Unclassified errors become internal errors.
+func ErrorStatus(err error) (*domain.Error, int) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = domain.ErrNotFound
+	}
+	domainErr := domain.AsError(err)
+	status, ok := errorStatuses[domainErr.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
 	}
+	return domainErr, status
+}
+
+// handleError writes err as a JSON error body. Unclassified errors become
+// internal errors whose cause stays in the logs, not in the response.
+func handleError(w http.ResponseWriter, span trace.Span, err error) {
+	domainErr, status := ErrorStatus(err)
+
+	response := dto.ErrorResponse{
+		Code:       domainErr.Code,
+		Message:    domainErr.Message,
+		StatusCode: status,
+	}
+	for _, field := range domainErr.Fields {
+		response.Details = append(response.Details, dto.FieldErrorDTO{
+			Field:   field.Field,
+			Code:    field.Code,
+			Message: field.Message,
+		})
+	}
+	if span != nil && span.SpanContext().HasTraceID() {
+		response.TraceId = span.SpanContext().TraceID().String()
+	}
+	writeResponse(w, status, response)
 }
 
 func writeResponse(w http.ResponseWriter, httpStatus int, data interface{}) {
 	w.Header().Set(domain.ContentType, domain.JsonContentType)
 	w.WriteHeader(httpStatus)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		handleError(w, http.StatusInternalServerError, err.Error())
+		log.Printf("error writing response: %v", err)
 	}
 }
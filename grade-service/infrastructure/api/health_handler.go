@@ -0,0 +1,39 @@
+package api
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/application"
+	"net/http"
+)
+
+type HealthHandler struct {
+	healthService *application.HealthService
+}
+
+func NewHealthHandler(healthService *application.HealthService) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// Init registers the probes outside the grade context path; the kubelet
+// calls them directly, not through the ingress.
+func (handler *HealthHandler) Init(router *mux.Router) {
+	router.HandleFunc("/livez", handler.Livez).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", handler.Readyz).Methods(http.MethodGet)
+}
+
+// Livez only reports that the process serves HTTP. Dependencies are left to
+// readiness, so an outage of Mongo or Kafka does not restart every pod.
+func (handler *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, http.StatusOK, map[string]string{"status": application.ComponentUp})
+}
+
+func (handler *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	readiness := handler.healthService.CheckReadiness(r.Context())
+	status := http.StatusOK
+	if readiness.Status != application.ComponentUp {
+		status = http.StatusServiceUnavailable
+	}
+	writeResponse(w, status, readiness)
+}
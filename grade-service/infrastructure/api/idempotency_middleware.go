@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const maxIdempotencyKeyLength = 255
+
+var errInvalidIdempotencyKey = domain.NewValidationError("invalid_idempotency_key", "Idempotency-Key must be at most "+strconv.Itoa(maxIdempotencyKeyLength)+" characters")
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (recorder *responseRecorder) WriteHeader(status int) {
+	recorder.status = status
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	recorder.body.Write(data)
+	return recorder.ResponseWriter.Write(data)
+}
+
+// Idempotency replays the stored response when a client repeats a write
+// with the same Idempotency-Key, so retries after a timeout do not create
+// the review or send the notification twice.
+//
+// A key is reserved for the lease while its first request is handled and kept
+// for the TTL once it has a response. The lease runs out if the replica dies
+// mid-request, so the key does not stay in progress for the whole TTL.
+type Idempotency struct {
+	store  domain.IdempotencyStore
+	ttl    time.Duration
+	lease  time.Duration
+	routes map[string]bool
+}
+
+func NewIdempotency(store domain.IdempotencyStore, ttl time.Duration, lease time.Duration) *Idempotency {
+	return &Idempotency{
+		store:  store,
+		ttl:    ttl,
+		lease:  lease,
+		routes: make(map[string]bool),
+	}
+}
+
+// Protect honours Idempotency-Key on the route registered with the given
+// method and path template, in every API version. Other routes ignore the
+// header.
+func (idempotency *Idempotency) Protect(method string, pathTemplate string) *Idempotency {
+	idempotency.routes[routeName(method, pathTemplate)] = true
+	return idempotency
+}
+
+// Middleware keys requests by JWT subject and Idempotency-Key, and matches
+// them by a hash of method, API version, unversioned URI and body, so a
+// retry of /grade on /grade/v1 is the same request. Responses with a server error are
+// not kept, so the client can retry them. If the store fails the request is
+// handled as if it had no key.
+func (idempotency *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(domain.IdempotencyKeyHeader)
+		pathTemplate, ok := unversionedRoute(r)
+		if key == "" || !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !idempotency.routes[routeName(r.Method, pathTemplate)] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		span := trace.SpanFromContext(r.Context())
+		if len(key) > maxIdempotencyKeyLength {
+			handleError(w, span, errInvalidIdempotencyKey)
+			return
+		}
+		claims, err := getClaims(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			handleError(w, span, domain.NewValidationError("invalid_payload", "Invalid request payload"))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := claims.Subject + "|" + key
+		requestHash := hashRequest(r, body)
+		stored, err := idempotency.store.Reserve(&domain.IdempotentRequest{
+			Key:         storeKey,
+			RequestHash: requestHash,
+			ExpiresAt:   time.Now().Add(idempotency.lease),
+		})
+		if err != nil {
+			log.Printf("idempotency store failed: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if stored != nil {
+			replay(w, span, stored, requestHash)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status >= http.StatusInternalServerError {
+			err = idempotency.store.Release(storeKey)
+		} else {
+			err = idempotency.store.Complete(storeKey, domain.IdempotentResponse{
+				StatusCode:  recorder.status,
+				ContentType: recorder.Header().Get(domain.ContentType),
+				ETag:        recorder.Header().Get(etagHeader),
+				Body:        recorder.body.Bytes(),
+			}, time.Now().Add(idempotency.ttl))
+		}
+		if err != nil {
+			log.Printf("idempotency store failed: %v", err)
+		}
+	})
+}
+
+func replay(w http.ResponseWriter, span trace.Span, stored *domain.IdempotentRequest, requestHash string) {
+	if stored.RequestHash != requestHash {
+		handleError(w, span, domain.ErrIdempotencyKeyReused)
+		return
+	}
+	if stored.Response == nil {
+		handleError(w, span, domain.ErrRequestInProgress)
+		return
+	}
+	if stored.Response.ContentType != "" {
+		w.Header().Set(domain.ContentType, stored.Response.ContentType)
+	}
+	if stored.Response.ETag != "" {
+		w.Header().Set(etagHeader, stored.Response.ETag)
+	}
+	w.Header().Set(domain.IdempotentReplayedHeader, "true")
+	w.WriteHeader(stored.Response.StatusCode)
+	if _, err := w.Write(stored.Response.Body); err != nil {
+		log.Printf("error writing response: %v", err)
+	}
+}
+
+func hashRequest(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	version, path := splitVersion(r.URL.Path)
+	hash.Write([]byte(r.Method + " " + version + " " + path + "?" + r.URL.RawQuery + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
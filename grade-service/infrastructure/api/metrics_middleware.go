@@ -0,0 +1,50 @@
+package api
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const unmatchedRoute = "unmatched"
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	recorder.status = status
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+// MetricsMiddleware records request counts and latencies labelled with the
+// route template rather than the path, which keeps ids out of the labels.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		route := unmatchedRoute
+		if currentRoute := mux.CurrentRoute(r); currentRoute != nil {
+			if pathTemplate, err := currentRoute.GetPathTemplate(); err == nil {
+				route = pathTemplate
+			}
+		}
+		status := strconv.Itoa(recorder.status)
+		metrics.HttpRequests.WithLabelValues(route, r.Method, status).Inc()
+		metrics.HttpRequestDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// InstrumentUnmatched records requests that match no route, which router
+// middlewares never see, under the unmatched route label.
+func InstrumentUnmatched(router *mux.Router) {
+	router.NotFoundHandler = MetricsMiddleware(http.NotFoundHandler())
+	router.MethodNotAllowedHandler = MetricsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+}
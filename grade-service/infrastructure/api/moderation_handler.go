@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/application"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/request"
+	"github.com/mmmajder/zms-devops-grade-service/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"net/http"
+)
+
+type ModerationHandler struct {
+	moderationService *application.ModerationService
+	traceProvider     *sdktrace.TracerProvider
+	loki              promtail.Client
+}
+
+func NewModerationHandler(moderationService *application.ModerationService, traceProvider *sdktrace.TracerProvider, loki promtail.Client) *ModerationHandler {
+	return &ModerationHandler{
+		moderationService: moderationService,
+		traceProvider:     traceProvider,
+		loki:              loki,
+	}
+}
+
+func (handler *ModerationHandler) Init(router *mux.Router) {
+	router.HandleFunc("/reports", handler.GetReportedReviews).Methods(http.MethodGet)
+	router.HandleFunc("/suspicions", handler.GetSuspicions).Methods(http.MethodGet)
+	router.HandleFunc("/{id}/report", handler.ReportReview).Methods(http.MethodPost)
+	router.HandleFunc("/{id}/restore", handler.RestoreReview).Methods(http.MethodPost)
+}
+
+func (handler *ModerationHandler) ReportReview(w http.ResponseWriter, r *http.Request) {
+	_, span := handler.traceProvider.Tracer(domain.ServiceName).Start(r.Context(), "report-review-post")
+	defer func() { span.End() }()
+	reviewPrimitiveId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		util.HttpTraceError(err, "invalid review id", span, handler.loki, "ReportReview", "")
+		handleError(w, span, errInvalidId)
+		return
+	}
+
+	claims, err := getClaims(r)
+	if err != nil {
+		util.HttpTraceError(err, "missing reporter", span, handler.loki, "ReportReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	var reportRequest request.ReportReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&reportRequest); err != nil {
+		util.HttpTraceError(err, "invalid report payload", span, handler.loki, "ReportReview", "")
+		handleError(w, span, domain.NewValidationError("invalid_payload", "Invalid report payload"))
+		return
+	}
+
+	if err := reportRequest.AreValidRequestData(); err != nil {
+		util.HttpTraceError(err, "invalid request data", span, handler.loki, "ReportReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	err = handler.moderationService.Report(reviewPrimitiveId, claims.Subject, domain.ReportReason(reportRequest.Reason), reportRequest.Text, span, handler.loki)
+	if err != nil {
+		util.HttpTraceError(err, "failed to report review", span, handler.loki, "ReportReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	util.HttpTraceInfo("Review reported successfully", span, handler.loki, "ReportReview", "")
+	writeResponse(w, http.StatusCreated, nil)
+}
+
+func (handler *ModerationHandler) GetReportedReviews(w http.ResponseWriter, r *http.Request) {
+	_, span := handler.traceProvider.Tracer(domain.ServiceName).Start(r.Context(), "get-reported-reviews-get")
+	defer func() { span.End() }()
+	if err := authorizeAdmin(r); err != nil {
+		util.HttpTraceError(err, "moderator is not authorized", span, handler.loki, "GetReportedReviews", "")
+		handleError(w, span, err)
+		return
+	}
+
+	response, err := handler.moderationService.GetReportedReviews(span, handler.loki)
+	if err != nil {
+		util.HttpTraceError(err, "failed to fetch reported reviews", span, handler.loki, "GetReportedReviews", "")
+		handleError(w, span, err)
+		return
+	}
+
+	util.HttpTraceInfo("Successfully fetched reported reviews", span, handler.loki, "GetReportedReviews", "")
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (handler *ModerationHandler) GetSuspicions(w http.ResponseWriter, r *http.Request) {
+	_, span := handler.traceProvider.Tracer(domain.ServiceName).Start(r.Context(), "get-suspicions-get")
+	defer func() { span.End() }()
+	if err := authorizeAdmin(r); err != nil {
+		util.HttpTraceError(err, "moderator is not authorized", span, handler.loki, "GetSuspicions", "")
+		handleError(w, span, err)
+		return
+	}
+
+	response, err := handler.moderationService.GetSuspicions(span, handler.loki)
+	if err != nil {
+		util.HttpTraceError(err, "failed to fetch suspicions", span, handler.loki, "GetSuspicions", "")
+		handleError(w, span, err)
+		return
+	}
+
+	util.HttpTraceInfo("Successfully fetched suspicions", span, handler.loki, "GetSuspicions", "")
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (handler *ModerationHandler) RestoreReview(w http.ResponseWriter, r *http.Request) {
+	_, span := handler.traceProvider.Tracer(domain.ServiceName).Start(r.Context(), "restore-review-post")
+	defer func() { span.End() }()
+	if err := authorizeAdmin(r); err != nil {
+		util.HttpTraceError(err, "moderator is not authorized", span, handler.loki, "RestoreReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	reviewPrimitiveId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		util.HttpTraceError(err, "invalid review id", span, handler.loki, "RestoreReview", "")
+		handleError(w, span, errInvalidId)
+		return
+	}
+
+	response, err := handler.moderationService.Restore(reviewPrimitiveId, span, handler.loki)
+	if err != nil {
+		util.HttpTraceError(err, "failed to restore review", span, handler.loki, "RestoreReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	util.HttpTraceInfo("Review restored successfully", span, handler.loki, "RestoreReview", "")
+	setETag(w, response.Version)
+	writeResponse(w, http.StatusOK, response)
+}
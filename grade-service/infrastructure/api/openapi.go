@@ -0,0 +1,106 @@
+package api
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"strings"
+)
+
+var (
+	//go:embed openapi-v1.json
+	openAPIV1 []byte
+	//go:embed openapi-v2-patch.json
+	openAPIV2Patch []byte
+
+	// openAPIDocuments holds the document of each API version with the
+	// unversioned /grade paths. v2 is v1 changed by a JSON merge patch, so
+	// the operations both versions share are written once.
+	openAPIDocuments = map[string][]byte{apiV1: openAPIV1, apiV2: mustMergePatch(openAPIV1, openAPIV2Patch)}
+)
+
+// loadOpenAPI parses the embedded document and validates it, so a broken
+// reference fails at startup instead of on the first request.
+func loadOpenAPI(data []byte) (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	document, err := loader.LoadFromData(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	if err := document.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	return document, nil
+}
+
+// mustMergePatch applies the JSON merge patch (RFC 7386) to the document and
+// panics when either is not JSON, as both are embedded.
+func mustMergePatch(document []byte, patch []byte) []byte {
+	documentValue, err := decodeJSON(document)
+	if err != nil {
+		panic(fmt.Sprintf("invalid OpenAPI document: %v", err))
+	}
+	patchValue, err := decodeJSON(patch)
+	if err != nil {
+		panic(fmt.Sprintf("invalid OpenAPI patch: %v", err))
+	}
+	merged, err := json.MarshalIndent(mergePatch(documentValue, patchValue), "", "  ")
+	if err != nil {
+		panic(fmt.Sprintf("invalid OpenAPI patch: %v", err))
+	}
+	return merged
+}
+
+// mergePatch returns the document with the members of the patch merged in
+// and the members the patch sets to null removed. The document is not changed.
+func mergePatch(document interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	documentObject, _ := document.(map[string]interface{})
+	merged := make(map[string]interface{}, len(documentObject))
+	for name, value := range documentObject {
+		merged[name] = value
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = mergePatch(merged[name], value)
+	}
+	return merged
+}
+
+// rebasePaths moves the /grade paths of the document under prefix, so the
+// document served under /grade/v1 or /grade/v2 describes the paths it is
+// served under.
+func rebasePaths(document []byte, prefix string) ([]byte, error) {
+	value, err := decodeJSON(document)
+	if err != nil {
+		return nil, err
+	}
+	object, _ := value.(map[string]interface{})
+	paths, _ := object["paths"].(map[string]interface{})
+	rebased := make(map[string]interface{}, len(paths))
+	for path, operations := range paths {
+		rebased[prefix+strings.TrimPrefix(path, domain.GradeContextPath)] = operations
+	}
+	object["paths"] = rebased
+	return json.MarshalIndent(object, "", "  ")
+}
+
+// decodeJSON keeps numbers as written, so a document survives a round trip.
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"embed"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.opentelemetry.io/otel/trace"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// swaggerUIAssets are the Swagger UI files the docs pages load. They are
+// served from the binary instead of a CDN; the Dockerfile puts the pinned
+// release in place before building, see swagger-ui/README.md.
+//
+//go:embed swagger-ui
+var swaggerUIAssets embed.FS
+
+// swaggerUIContentTypes lists the assets the docs pages load.
+var swaggerUIContentTypes = map[string]string{
+	"swagger-ui.css":       "text/css; charset=utf-8",
+	"swagger-ui-bundle.js": "text/javascript; charset=utf-8",
+}
+
+var errSwaggerUIAssetNotFound = domain.NewNotFoundError("asset_not_found", "asset not found")
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Grade service API</title>
+  <link rel="stylesheet" href=%q>
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src=%q></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: %q, dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+// OpenAPIHandler serves the OpenAPI document of the API version it is mounted
+// under, with the paths under the same prefix, and a Swagger UI page that
+// renders it.
+type OpenAPIHandler struct{}
+
+func NewOpenAPIHandler() *OpenAPIHandler {
+	return &OpenAPIHandler{}
+}
+
+// Init has to run before ReviewHandler.Init, whose GET /{id} would otherwise
+// match these paths.
+func (handler *OpenAPIHandler) Init(router *mux.Router) {
+	router.HandleFunc("/openapi.json", handler.GetOpenAPI).Methods(http.MethodGet)
+	router.HandleFunc("/docs", handler.GetDocs).Methods(http.MethodGet)
+	router.HandleFunc("/docs/{asset}", handler.GetDocsAsset).Methods(http.MethodGet)
+}
+
+func (handler *OpenAPIHandler) GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	document, err := rebasePaths(openAPIDocuments[requestVersion(r)], strings.TrimSuffix(r.URL.Path, "/openapi.json"))
+	if err != nil {
+		handleError(w, trace.SpanFromContext(r.Context()), err)
+		return
+	}
+	w.Header().Set(domain.ContentType, domain.JsonContentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(document); err != nil {
+		log.Printf("error writing response: %v", err)
+	}
+}
+
+func (handler *OpenAPIHandler) GetDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(domain.ContentType, "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	prefix := strings.TrimSuffix(r.URL.Path, "/docs")
+	if _, err := fmt.Fprintf(w, swaggerUIPage, prefix+"/docs/swagger-ui.css", prefix+"/docs/swagger-ui-bundle.js", prefix+"/openapi.json"); err != nil {
+		log.Printf("error writing response: %v", err)
+	}
+}
+
+func (handler *OpenAPIHandler) GetDocsAsset(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["asset"]
+	contentType, ok := swaggerUIContentTypes[name]
+	if !ok {
+		handleError(w, trace.SpanFromContext(r.Context()), errSwaggerUIAssetNotFound)
+		return
+	}
+	asset, err := swaggerUIAssets.ReadFile("swagger-ui/" + name)
+	if err != nil {
+		handleError(w, trace.SpanFromContext(r.Context()), errSwaggerUIAssetNotFound)
+		return
+	}
+	w.Header().Set(domain.ContentType, contentType)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(asset); err != nil {
+		log.Printf("error writing response: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/metrics"
+	"go.opentelemetry.io/otel/trace"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	subjectKey = "subject"
+	clientKey  = "client"
+)
+
+type RateLimiter struct {
+	store       domain.RateLimitStore
+	trustedHops int
+	rules       map[string]domain.RateLimit
+	aliases     map[string]string
+}
+
+// NewRateLimiter identifies clients by the X-Forwarded-For entry added by the
+// outermost of trustedHops proxies in front of the service. With no trusted
+// hops the connection address is used.
+func NewRateLimiter(store domain.RateLimitStore, trustedHops int) *RateLimiter {
+	return &RateLimiter{
+		store:       store,
+		trustedHops: trustedHops,
+		rules:       make(map[string]domain.RateLimit),
+		aliases:     make(map[string]string),
+	}
+}
+
+// Limit throttles the route registered with the given method and path
+// template, in every API version. Routes without a rule, or with a limit of no
+// requests, are not throttled.
+func (limiter *RateLimiter) Limit(method string, pathTemplate string, limit domain.RateLimit) *RateLimiter {
+	if !limit.IsValid() {
+		log.Printf("rate limit of %s is disabled", routeName(method, pathTemplate))
+		return limiter
+	}
+	limiter.rules[routeName(method, pathTemplate)] = limit
+	return limiter
+}
+
+// Alias counts requests to a route against the limit of another route, so
+// an alternative way to do the same thing does not double the budget.
+func (limiter *RateLimiter) Alias(method string, pathTemplate string, targetMethod string, targetPathTemplate string) *RateLimiter {
+	limiter.aliases[routeName(method, pathTemplate)] = routeName(targetMethod, targetPathTemplate)
+	return limiter
+}
+
+// Middleware keeps one bucket per JWT subject and one per client IP, so a
+// user cannot dodge the limit by switching addresses and many anonymous
+// requests from one address are still throttled. A request rejected by one
+// bucket gets back the tokens it took from the others. If the store fails the
+// request is let through.
+func (limiter *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pathTemplate, ok := unversionedRoute(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		name := routeName(r.Method, pathTemplate)
+		if target, ok := limiter.aliases[name]; ok {
+			name = target
+		}
+		limit, ok := limiter.rules[name]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		keys := map[string]string{clientKey: limiter.clientIP(r)}
+		if claims, err := getClaims(r); err == nil {
+			keys[subjectKey] = claims.Subject
+		}
+		var taken []string
+		for _, keyType := range []string{subjectKey, clientKey} {
+			key, ok := keys[keyType]
+			if !ok {
+				continue
+			}
+			bucket := name + "|" + keyType + "|" + key
+			allowed, retryAfter, err := limiter.store.Take(bucket, limit)
+			if err != nil {
+				log.Printf("rate limit store failed: %v", err)
+				continue
+			}
+			if !allowed {
+				limiter.refund(taken, limit)
+				metrics.RateLimitRejections.WithLabelValues(name, keyType).Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				handleError(w, trace.SpanFromContext(r.Context()), domain.ErrRateLimited)
+				return
+			}
+			taken = append(taken, bucket)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (limiter *RateLimiter) refund(buckets []string, limit domain.RateLimit) {
+	for _, bucket := range buckets {
+		if err := limiter.store.Refund(bucket, limit); err != nil {
+			log.Printf("rate limit store failed: %v", err)
+		}
+	}
+}
+
+func routeName(method string, pathTemplate string) string {
+	return method + " " + pathTemplate
+}
+
+// clientIP reads X-Forwarded-For from the right: every trusted proxy appends
+// the address it was called from, so the entry trustedHops from the end is
+// the caller seen by the outermost trusted proxy. Entries to its left come
+// from the caller and can be forged. Without enough entries the connection
+// address is used.
+func (limiter *RateLimiter) clientIP(r *http.Request) string {
+	var forwarded []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+	if limiter.trustedHops > 0 && len(forwarded) >= limiter.trustedHops {
+		if address := strings.TrimSpace(forwarded[len(forwarded)-limiter.trustedHops]); address != "" {
+			return address
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,324 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const maxValidatedBodySize = 1 << 20
+
+var errRequestTooLarge = domain.NewError(domain.KindTooLarge, "payload_too_large", "request body is too large")
+
+// RequestValidator rejects requests whose parameters or JSON body do not
+// match the operation the OpenAPI document of their API version describes
+// for their route, with the same error shape the handlers use. Routes
+// missing from the document and non-JSON bodies are left to the handlers.
+type RequestValidator struct {
+	documents map[string]*openapi3.T
+}
+
+func NewRequestValidator() (*RequestValidator, error) {
+	validator := &RequestValidator{
+		documents: make(map[string]*openapi3.T),
+	}
+	for version, data := range openAPIDocuments {
+		document, err := loadOpenAPI(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", version, err)
+		}
+		registerJSONDecoders(document)
+		validator.documents[version] = document
+	}
+	return validator, nil
+}
+
+func (validator *RequestValidator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		pathTemplate, _ := route.GetPathTemplate()
+		version, pathTemplate := splitVersion(pathTemplate)
+		document := validator.documents[version]
+		pathItem := document.Paths[pathTemplate]
+		if pathItem == nil || pathItem.GetOperation(r.Method) == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		err := validate(r, &routers.Route{
+			Spec:      document,
+			Path:      pathTemplate,
+			PathItem:  pathItem,
+			Method:    r.Method,
+			Operation: pathItem.GetOperation(r.Method),
+		})
+		if err != nil {
+			handleError(w, trace.SpanFromContext(r.Context()), err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validate checks the request with kin-openapi. The body is read here first,
+// so that its size is limited, a missing Content-Type means JSON and the
+// handler still gets the body.
+func validate(r *http.Request, route *routers.Route) error {
+	options := &openapi3filter.Options{
+		MultiError:          true,
+		SkipSettingDefaults: true,
+		AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+	}
+	validated := r.Clone(r.Context())
+	if body := route.Operation.RequestBody; body != nil {
+		data, mediaType, err := readBody(r, body.Value)
+		if err != nil {
+			return err
+		}
+		validated.Header.Set(domain.ContentType, mediaType)
+		validated.Body = io.NopCloser(bytes.NewReader(data))
+		validated.GetBody = nil
+		options.ExcludeRequestBody = data == nil
+	}
+
+	err := openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+		Request:    validated,
+		PathParams: mux.Vars(r),
+		Route:      route,
+		Options:    options,
+	})
+	if err == nil {
+		return nil
+	}
+	return requestError(err)
+}
+
+// readBody returns the JSON body to validate and its media type, or no body
+// when it is empty and optional or is not JSON.
+func readBody(r *http.Request, body *openapi3.RequestBody) ([]byte, string, error) {
+	mediaType := domain.JsonContentType
+	if contentType := r.Header.Get(domain.ContentType); contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, "", unsupportedMediaType(body.Content)
+		}
+		mediaType = parsed
+	}
+	if body.Content[mediaType] == nil {
+		return nil, "", unsupportedMediaType(body.Content)
+	}
+	if !isJSON(mediaType) {
+		return nil, mediaType, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxValidatedBodySize+1))
+	if err != nil {
+		return nil, "", domain.NewValidationError("invalid_payload", "Invalid request payload")
+	}
+	if len(data) > maxValidatedBodySize {
+		return nil, "", errRequestTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		if body.Required {
+			return nil, "", domain.NewValidationError("invalid_payload", "Request body is required")
+		}
+		return nil, mediaType, nil
+	}
+	if !json.Valid(data) {
+		return nil, "", domain.NewValidationError("invalid_payload", "Request body is not valid JSON")
+	}
+	return data, mediaType, nil
+}
+
+// requestError turns the errors kin-openapi reports into one validation
+// error with a field error per invalid parameter or body member.
+func requestError(err error) error {
+	var requestErrors openapi3.MultiError
+	if !errors.As(err, &requestErrors) {
+		requestErrors = openapi3.MultiError{err}
+	}
+
+	fields := make([]domain.FieldError, 0)
+	for _, err := range requestErrors {
+		var requestErr *openapi3filter.RequestError
+		if !errors.As(err, &requestErr) {
+			return err
+		}
+		if parameter := requestErr.Parameter; parameter != nil {
+			parameterFields := parameterErrors(parameter, requestErr.Err)
+			if len(parameterFields) > 0 && parameter.In == openapi3.ParameterInPath && parameter.Name == "id" {
+				// The same error the handlers return for an id they can not parse.
+				return errInvalidId
+			}
+			fields = append(fields, parameterFields...)
+			continue
+		}
+		for _, schemaErr := range schemaErrors(requestErr.Err) {
+			field := fieldError(schemaErr, pointerField(schemaErr.JSONPointer()))
+			if field.Field == "" {
+				return domain.NewValidationError("invalid_payload", field.Message)
+			}
+			fields = append(fields, field)
+		}
+	}
+	return invalidRequest(fields)
+}
+
+func parameterErrors(parameter *openapi3.Parameter, err error) []domain.FieldError {
+	var parseErr *openapi3filter.ParseError
+	switch {
+	case errors.Is(err, openapi3filter.ErrInvalidRequired), errors.Is(err, openapi3filter.ErrInvalidEmptyValue):
+		return []domain.FieldError{{Field: parameter.Name, Code: "required", Message: parameter.Name + " is required"}}
+	case errors.As(err, &parseErr):
+		return []domain.FieldError{typeError(parameter.Name, parameter.Schema.Value.Type)}
+	}
+	fields := make([]domain.FieldError, 0)
+	for _, schemaErr := range schemaErrors(err) {
+		fields = append(fields, fieldError(schemaErr, parameter.Name))
+	}
+	return fields
+}
+
+func schemaErrors(err error) []*openapi3.SchemaError {
+	var multiErr openapi3.MultiError
+	if errors.As(err, &multiErr) {
+		schemaErrs := make([]*openapi3.SchemaError, 0, len(multiErr))
+		for _, err := range multiErr {
+			schemaErrs = append(schemaErrs, schemaErrors(err)...)
+		}
+		return schemaErrs
+	}
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		return []*openapi3.SchemaError{schemaErr}
+	}
+	return []*openapi3.SchemaError{{SchemaField: "invalid", Reason: err.Error()}}
+}
+
+// fieldError describes the schema error of the value at field, which is
+// empty for the body itself.
+func fieldError(err *openapi3.SchemaError, field string) domain.FieldError {
+	schema := err.Schema
+	if schema == nil {
+		schema = &openapi3.Schema{}
+	}
+	switch err.SchemaField {
+	case "required":
+		return domain.FieldError{Field: field, Code: "required", Message: field + " is required"}
+	case "type":
+		return typeError(field, schema.Type)
+	case "nullable":
+		return domain.FieldError{Field: field, Code: "type", Message: subject(field) + " must not be null"}
+	case "properties":
+		name, unquoteErr := strconv.Unquote(strings.TrimSuffix(strings.TrimPrefix(err.Reason, "property "), " is unsupported"))
+		if unquoteErr != nil {
+			break
+		}
+		return domain.FieldError{Field: joinField(field, name), Code: "unknown", Message: joinField(field, name) + " is not allowed"}
+	case "minLength":
+		return domain.FieldError{Field: field, Code: "min", Message: fmt.Sprintf("%s must be at least %d characters", subject(field), schema.MinLength)}
+	case "maxLength":
+		return domain.FieldError{Field: field, Code: "max", Message: fmt.Sprintf("%s must be at most %d characters", subject(field), *schema.MaxLength)}
+	case "minimum":
+		return domain.FieldError{Field: field, Code: "min", Message: fmt.Sprintf("%s must be at least %v", subject(field), *schema.Min)}
+	case "maximum":
+		return domain.FieldError{Field: field, Code: "max", Message: fmt.Sprintf("%s must be at most %v", subject(field), *schema.Max)}
+	case "pattern":
+		return domain.FieldError{Field: field, Code: "pattern", Message: subject(field) + " is malformed"}
+	case "enum":
+		options := make([]string, 0, len(schema.Enum))
+		for _, option := range schema.Enum {
+			options = append(options, fmt.Sprint(option))
+		}
+		return domain.FieldError{Field: field, Code: "oneof", Message: fmt.Sprintf("%s must be one of: %s", subject(field), strings.Join(options, ", "))}
+	}
+	return domain.FieldError{Field: field, Code: "invalid", Message: subject(field) + " is invalid"}
+}
+
+// pointerField writes the JSON pointer of a body member as a dotted path
+// with array indexes in brackets.
+func pointerField(pointer []string) string {
+	field := ""
+	for _, part := range pointer {
+		if _, err := strconv.Atoi(part); err == nil {
+			field += "[" + part + "]"
+			continue
+		}
+		field = joinField(field, part)
+	}
+	return field
+}
+
+// registerJSONDecoders lets kin-openapi decode the +json media types of the
+// document, such as application/merge-patch+json, as JSON.
+func registerJSONDecoders(document *openapi3.T) {
+	for _, pathItem := range document.Paths {
+		for _, operation := range pathItem.Operations() {
+			if operation.RequestBody == nil {
+				continue
+			}
+			for mediaType := range operation.RequestBody.Value.Content {
+				if isJSON(mediaType) && openapi3filter.RegisteredBodyDecoder(mediaType) == nil {
+					openapi3filter.RegisterBodyDecoder(mediaType, openapi3filter.RegisteredBodyDecoder(domain.JsonContentType))
+				}
+			}
+		}
+	}
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == domain.JsonContentType || strings.HasSuffix(mediaType, "+json")
+}
+
+func invalidRequest(fields []domain.FieldError) error {
+	return domain.NewValidationError("invalid_request", "Request data is invalid", fields...)
+}
+
+func unsupportedMediaType(content openapi3.Content) error {
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	return domain.NewError(domain.KindUnsupported, "unsupported_media_type", "Content-Type must be one of: "+strings.Join(mediaTypes, ", "))
+}
+
+func typeError(field string, schemaType string) domain.FieldError {
+	article := "a "
+	if schemaType == "integer" || schemaType == "object" || schemaType == "array" {
+		article = "an "
+	}
+	return domain.FieldError{Field: field, Code: "type", Message: subject(field) + " must be " + article + schemaType}
+}
+
+func joinField(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func subject(field string) string {
+	if field == "" {
+		return "request body"
+	}
+	return field
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSearchLimit   = 20
+	maxSearchLimit       = 100
+	maxSearchQueryLength = 200
+	defaultPageSize      = 20
+	maxPageSize          = 100
+	dateLayout           = "2006-01-02"
+)
+
+// parseReviewFilter reads the stars, from, to and lang query parameters.
+// Dates are either RFC 3339 timestamps or plain dates, where a plain "to"
+// date includes the whole day.
+func parseReviewFilter(r *http.Request) (domain.ReviewFilter, error) {
+	query := r.URL.Query()
+	var filter domain.ReviewFilter
+
+	if value := query.Get("stars"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			stars, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || stars < 1 || stars > 5 {
+				return domain.ReviewFilter{}, domain.NewValidationErrorf("invalid_filter", "invalid stars value %q", part)
+			}
+			filter.Stars = append(filter.Stars, stars)
+		}
+	}
+
+	if value := query.Get("from"); value != "" {
+		from, _, err := parseDate(value)
+		if err != nil {
+			return domain.ReviewFilter{}, domain.NewValidationErrorf("invalid_filter", "invalid from date %q", value)
+		}
+		filter.From = &from
+	}
+
+	if value := query.Get("to"); value != "" {
+		to, dateOnly, err := parseDate(value)
+		if err != nil {
+			return domain.ReviewFilter{}, domain.NewValidationErrorf("invalid_filter", "invalid to date %q", value)
+		}
+		if dateOnly {
+			to = to.Add(24*time.Hour - time.Nanosecond)
+		}
+		filter.To = &to
+	}
+
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return domain.ReviewFilter{}, domain.NewValidationError("invalid_filter", "from date is after to date")
+	}
+
+	filter.Language = baseLanguage(query.Get("lang"))
+	return filter, nil
+}
+
+// parsePage reads the page and pageSize query parameters. The first page is
+// the default.
+func parsePage(r *http.Request) (domain.Page, error) {
+	query := r.URL.Query()
+	page := domain.Page{Number: 1, Size: defaultPageSize}
+	if value := query.Get("page"); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			return domain.Page{}, domain.NewValidationErrorf("invalid_page", "invalid page %q", value)
+		}
+		page.Number = number
+	}
+	if value := query.Get("pageSize"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > maxPageSize {
+			return domain.Page{}, domain.NewValidationErrorf("invalid_page", "page size must be between 1 and %d", maxPageSize)
+		}
+		page.Size = size
+	}
+	return page, nil
+}
+
+func parseDate(value string) (time.Time, bool, error) {
+	if date, err := time.Parse(dateLayout, value); err == nil {
+		return date, true, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	return date, false, err
+}
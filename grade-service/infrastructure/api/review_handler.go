@@ -7,12 +7,14 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/mmmajder/zms-devops-grade-service/application"
 	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
 	"github.com/mmmajder/zms-devops-grade-service/infrastructure/request"
 	"github.com/mmmajder/zms-devops-grade-service/util"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 type ReviewHandler struct {
@@ -30,133 +32,291 @@ func NewReviewHandler(reviewService *application.ReviewService, traceProvider *s
 }
 
 func (handler *ReviewHandler) Init(router *mux.Router) {
-	router.HandleFunc(domain.GradeContextPath, handler.AddReview).Methods(http.MethodPost)
-	router.HandleFunc(domain.GradeContextPath+"/{id}", handler.UpdateReview).Methods(http.MethodPut)
-	router.HandleFunc(domain.GradeContextPath+"/{sub-reviewed}/{type}", handler.GetAllReviewsBySubReviewed).Methods(http.MethodGet)
-	router.HandleFunc(domain.GradeContextPath+"/{id}/{type}", handler.DeleteReview).Methods(http.MethodDelete)
-	router.HandleFunc(domain.GradeContextPath+"/health", handler.GetHealthCheck).Methods(http.MethodGet)
+	handler.routes(router, apiV1)
+}
+
+// InitV2 registers v2 of the API. It names review types only by name, pages
+// the reviews of a subject and drops the deprecated delete route.
+func (handler *ReviewHandler) InitV2(router *mux.Router) {
+	handler.routes(router, apiV2)
+}
+
+func (handler *ReviewHandler) routes(router *mux.Router, version string) {
+	getReviews := handler.GetAllReviewsBySubReviewed
+	if version == apiV2 {
+		getReviews = handler.GetReviewPage
+	}
+	router.HandleFunc("", handler.AddReview).Methods(http.MethodPost)
+	router.HandleFunc("/{id}", handler.UpdateReview).Methods(http.MethodPut)
+	router.HandleFunc("/{id}", handler.PatchReview).Methods(http.MethodPatch)
+	router.HandleFunc("/{id}/helpful", handler.VoteHelpful).Methods(http.MethodPost)
+	router.HandleFunc("/{id}/not-helpful", handler.VoteNotHelpful).Methods(http.MethodPost)
+	router.HandleFunc("/{sub-reviewed}/{type}", getReviews).Methods(http.MethodGet)
+	router.HandleFunc("/{sub-reviewed}/{type}/search", handler.SearchReviews).Methods(http.MethodGet)
+	router.HandleFunc("/{id}", handler.DeleteReview).Methods(http.MethodDelete)
+	if version == apiV1 {
+		// Deprecated: the type is taken from the stored review.
+		router.HandleFunc("/{id}/{type}", handler.DeleteReview).Methods(http.MethodDelete)
+	}
+	router.HandleFunc("/health", handler.GetHealthCheck).Methods(http.MethodGet)
+	router.HandleFunc("/types", handler.GetReviewTypes).Methods(http.MethodGet)
+	// Registered last so that the fixed paths above take precedence.
+	router.HandleFunc("/{id}", handler.GetReview).Methods(http.MethodGet)
 }
 
 func (handler *ReviewHandler) GetHealthCheck(w http.ResponseWriter, r *http.Request) {
 	writeResponse(w, http.StatusOK, domain.HealthCheckMessage)
 }
 
+func (handler *ReviewHandler) GetReviewTypes(w http.ResponseWriter, r *http.Request) {
+	definitions := handler.reviewService.ReviewTypes().All()
+	reviewTypes := make([]dto.ReviewTypeDTO, 0, len(definitions))
+	for _, definition := range definitions {
+		reviewTypes = append(reviewTypes, definition.ToDTO())
+	}
+	writeResponse(w, http.StatusOK, reviewTypes)
+}
+
 func (handler *ReviewHandler) AddReview(w http.ResponseWriter, r *http.Request) {
 	_, span := handler.traceProvider.Tracer(domain.ServiceName).Start(r.Context(), "add-review-post")
 	defer func() { span.End() }()
 	var reviewRequest request.ReviewRequest
 	if err := json.NewDecoder(r.Body).Decode(&reviewRequest); err != nil {
 		util.HttpTraceError(err, "invalid review payload", span, handler.loki, "AddReview", "")
-		handleError(w, http.StatusBadRequest, "Invalid review payload")
+		handleError(w, span, domain.NewValidationError("invalid_payload", "Invalid review payload"))
 		return
 	}
 
 	if err := reviewRequest.AreValidRequestData(); err != nil {
 		util.HttpTraceError(err, "invalid request data", span, handler.loki, "AddReview", "")
-		handleError(w, http.StatusBadRequest, err.Error())
+		handleError(w, span, err)
+		return
+	}
+
+	definition, err := handler.parseReviewType(r, reviewRequest.ReviewType.String())
+	if err != nil {
+		util.HttpTraceError(err, "invalid review type", span, handler.loki, "AddReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	if err := authorizeReviewer(r, definition); err != nil {
+		util.HttpTraceError(err, "reviewer is not authorized", span, handler.loki, "AddReview", "")
+		handleError(w, span, err)
+		return
+	}
+	claims, err := getClaims(r)
+	if err != nil {
+		util.HttpTraceError(err, "missing reviewer", span, handler.loki, "AddReview", "")
+		handleError(w, span, err)
 		return
 	}
 
 	response, err := handler.reviewService.Add(
-		reviewRequest.ReviewType,
+		definition.Type,
 		reviewRequest.Comment,
 		reviewRequest.Grade,
+		baseLanguage(reviewRequest.Language),
 		reviewRequest.SubReviewer,
 		reviewRequest.SubReviewed,
 		reviewRequest.ReviewerFullName,
 		reviewRequest.HostId,
+		claims.accountCreatedAt(),
 		span, handler.loki,
 	)
 
 	if err != nil {
 		util.HttpTraceError(err, "failed to add review", span, handler.loki, "AddReview", "")
-		handleError(w, http.StatusInternalServerError, err.Error())
+		handleError(w, span, err)
 		return
 	}
 	util.HttpTraceInfo("Review added successfully", span, handler.loki, "AddReview", "")
 
+	setETag(w, response.Version)
 	writeResponse(w, http.StatusCreated, response)
 }
 
+func (handler *ReviewHandler) GetReview(w http.ResponseWriter, r *http.Request) {
+	_, span := handler.traceProvider.Tracer(domain.ServiceName).Start(r.Context(), "get-review-get")
+	defer func() { span.End() }()
+	reviewPrimitiveId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		util.HttpTraceError(err, "invalid review id", span, handler.loki, "GetReview", "")
+		handleError(w, span, errInvalidId)
+		return
+	}
+
+	review, err := handler.reviewService.Get(reviewPrimitiveId, span, handler.loki)
+	if err != nil {
+		util.HttpTraceError(err, "failed to get review", span, handler.loki, "GetReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	util.HttpTraceInfo("Successfully fetched review", span, handler.loki, "GetReview", "")
+	setETag(w, review.Version)
+	writeResponse(w, http.StatusOK, review)
+}
+
 func (handler *ReviewHandler) UpdateReview(w http.ResponseWriter, r *http.Request) {
 	_, span := handler.traceProvider.Tracer(domain.ServiceName).Start(r.Context(), "update-review-put")
 	defer func() { span.End() }()
 	id := mux.Vars(r)["id"]
 	if id == "" {
 		util.HttpTraceError(errors.New("review id can not empty"), "review id can not empty", span, handler.loki, "UpdateReview", "")
-		handleError(w, http.StatusBadRequest, domain.InvalidIDErrorMessage)
+		handleError(w, span, errInvalidId)
 		return
 	}
 
 	reviewPrimitiveId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		util.HttpTraceError(err, "invalid review id", span, handler.loki, "UpdateReview", "")
-		handleError(w, http.StatusBadRequest, domain.InvalidIDErrorMessage)
+		handleError(w, span, errInvalidId)
 		return
 	}
 
 	var updateReviewRequest request.UpdateReviewRequest
 	if err := json.NewDecoder(r.Body).Decode(&updateReviewRequest); err != nil {
 		util.HttpTraceError(err, "invalid update review payload", span, handler.loki, "UpdateReview", "")
-		handleError(w, http.StatusBadRequest, "Invalid update review payload")
+		handleError(w, span, domain.NewValidationError("invalid_payload", "Invalid update review payload"))
 		return
 	}
 
 	if err := updateReviewRequest.AreValidRequestData(); err != nil {
 		util.HttpTraceError(err, "invalid request data", span, handler.loki, "UpdateReview", "")
-		handleError(w, http.StatusBadRequest, err.Error())
+		handleError(w, span, err)
 		return
 	}
 
-	err = handler.reviewService.Update(
+	definition, err := handler.storedReviewType(w, r, reviewPrimitiveId, string(updateReviewRequest.ReviewType))
+	if err != nil {
+		util.HttpTraceError(err, "invalid review type", span, handler.loki, "UpdateReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	if err := authorizeReviewer(r, definition); err != nil {
+		util.HttpTraceError(err, "reviewer is not authorized", span, handler.loki, "UpdateReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	review, err := handler.reviewService.Update(
 		reviewPrimitiveId,
-		updateReviewRequest.ReviewType,
 		updateReviewRequest.Comment,
 		updateReviewRequest.Grade,
+		ifMatchVersions(r),
 		span, handler.loki,
 	)
 
 	if err != nil {
 		util.HttpTraceError(err, "failed to update review", span, handler.loki, "UpdateReview", "")
-		handleError(w, http.StatusInternalServerError, err.Error())
+		handleError(w, span, err)
 		return
 	}
 
 	util.HttpTraceInfo("Review updated successfully", span, handler.loki, "UpdateReview", "")
+	setETag(w, review.Version)
 	writeResponse(w, http.StatusOK, nil)
 }
 
+// PatchReview applies a JSON Merge Patch to a review. Unlike UpdateReview it
+// takes the review type from the stored review.
+func (handler *ReviewHandler) PatchReview(w http.ResponseWriter, r *http.Request) {
+	_, span := handler.traceProvider.Tracer(domain.ServiceName).Start(r.Context(), "patch-review-patch")
+	defer func() { span.End() }()
+	reviewPrimitiveId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		util.HttpTraceError(err, "invalid review id", span, handler.loki, "PatchReview", "")
+		handleError(w, span, errInvalidId)
+		return
+	}
+
+	var patchReviewRequest request.PatchReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&patchReviewRequest); err != nil {
+		util.HttpTraceError(err, "invalid review patch", span, handler.loki, "PatchReview", "")
+		handleError(w, span, domain.NewValidationError("invalid_payload", "Invalid review patch"))
+		return
+	}
+
+	if err := patchReviewRequest.AreValidRequestData(); err != nil {
+		util.HttpTraceError(err, "invalid request data", span, handler.loki, "PatchReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	definition, err := handler.reviewService.ReviewTypeOf(reviewPrimitiveId)
+	if err != nil {
+		util.HttpTraceError(err, "failed to get review type", span, handler.loki, "PatchReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	if err := authorizeReviewer(r, definition); err != nil {
+		util.HttpTraceError(err, "reviewer is not authorized", span, handler.loki, "PatchReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	patch := domain.ReviewPatch{
+		Comment:        patchReviewRequest.Comment,
+		Grade:          patchReviewRequest.Grade,
+		DetectLanguage: patchReviewRequest.ResetLanguage,
+	}
+	if patchReviewRequest.Language != nil {
+		language := baseLanguage(*patchReviewRequest.Language)
+		patch.Language = &language
+	}
+
+	review, err := handler.reviewService.Patch(reviewPrimitiveId, patch, ifMatchVersions(r), span, handler.loki)
+	if err != nil {
+		util.HttpTraceError(err, "failed to patch review", span, handler.loki, "PatchReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	util.HttpTraceInfo("Review patched successfully", span, handler.loki, "PatchReview", "")
+	setETag(w, review.Version)
+	writeResponse(w, http.StatusOK, review)
+}
+
 func (handler *ReviewHandler) DeleteReview(w http.ResponseWriter, r *http.Request) {
 	_, span := handler.traceProvider.Tracer(domain.ServiceName).Start(r.Context(), "delete-review-delete")
 	defer func() { span.End() }()
 	id := mux.Vars(r)["id"]
 	if id == "" {
 		util.HttpTraceError(errors.New("review id can not empty"), "review id can not empty", span, handler.loki, "DeleteReview", "")
-		handleError(w, http.StatusBadRequest, domain.InvalidIDErrorMessage)
+		handleError(w, span, errInvalidId)
 		return
 	}
 
 	reviewPrimitiveId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		util.HttpTraceError(err, "invalid review id", span, handler.loki, "DeleteReview", "")
-		handleError(w, http.StatusBadRequest, domain.InvalidIDErrorMessage)
+		handleError(w, span, errInvalidId)
 		return
 	}
 
-	reviewType, err := strconv.Atoi(mux.Vars(r)["type"])
+	definition, err := handler.storedReviewType(w, r, reviewPrimitiveId, mux.Vars(r)["type"])
 	if err != nil {
 		util.HttpTraceError(err, "invalid review type", span, handler.loki, "DeleteReview", "")
-		handleError(w, http.StatusBadRequest, "Invalid number for reviewed type")
+		handleError(w, span, err)
+		return
+	}
+
+	if err := authorizeReviewer(r, definition); err != nil {
+		util.HttpTraceError(err, "reviewer is not authorized", span, handler.loki, "DeleteReview", "")
+		handleError(w, span, err)
 		return
 	}
 
-	if err := handler.reviewService.Delete(reviewPrimitiveId, reviewType, span, handler.loki); err != nil {
+	if err := handler.reviewService.Delete(reviewPrimitiveId, span, handler.loki); err != nil {
 		util.HttpTraceError(err, "failed to delete review", span, handler.loki, "DeleteReview", "")
-		handleError(w, http.StatusInternalServerError, err.Error())
+		handleError(w, span, err)
 		return
 	}
 
-	util.HttpTraceInfo("Unavailability period added successfully", span, handler.loki, "DeleteReview", "")
+	util.HttpTraceInfo("Review deleted successfully", span, handler.loki, "DeleteReview", "")
 	writeResponse(w, http.StatusOK, nil)
 }
 
@@ -166,22 +326,217 @@ func (handler *ReviewHandler) GetAllReviewsBySubReviewed(w http.ResponseWriter,
 	subReviewed := mux.Vars(r)["sub-reviewed"]
 	if subReviewed == "" {
 		util.HttpTraceError(errors.New("review id can not empty"), "review id can not empty", span, handler.loki, "GetAllReviewsBySubReviewed", "")
-		handleError(w, http.StatusBadRequest, "Invalid ID of reviewed object")
+		handleError(w, span, domain.NewValidationError("invalid_id", "Invalid ID of reviewed object"))
 		return
 	}
 
-	reviewType, err := strconv.Atoi(mux.Vars(r)["type"])
+	definition, err := handler.reviewService.ReviewTypes().Parse(mux.Vars(r)["type"])
 	if err != nil {
 		util.HttpTraceError(err, "invalid review type", span, handler.loki, "GetAllReviewsBySubReviewed", "")
-		handleError(w, http.StatusBadRequest, "Invalid number for reviewed type")
+		handleError(w, span, err)
+		return
+	}
+
+	sortOrder := domain.ReviewSortOrder(r.URL.Query().Get("sort"))
+	if sortOrder != domain.SortUnordered && sortOrder != domain.SortNewest && sortOrder != domain.SortMostHelpful {
+		util.HttpTraceError(errors.New("invalid sort order"), "invalid sort order", span, handler.loki, "GetAllReviewsBySubReviewed", "")
+		handleError(w, span, domain.NewValidationError("invalid_sort_order", "Invalid sort order"))
 		return
 	}
 
-	response, err := handler.reviewService.GetAllBySubReviewed(subReviewed, reviewType, span, handler.loki)
+	filter, err := parseReviewFilter(r)
 	if err != nil {
-		handleError(w, http.StatusInternalServerError, err.Error())
+		util.HttpTraceError(err, "invalid review filter", span, handler.loki, "GetAllReviewsBySubReviewed", "")
+		handleError(w, span, err)
+		return
+	}
+
+	response, err := handler.reviewService.GetAllBySubReviewed(
+		subReviewed,
+		definition.Type,
+		filter,
+		sortOrder,
+		baseLanguage(r.URL.Query().Get("translateTo")),
+		span, handler.loki,
+	)
+	if err != nil {
+		util.HttpTraceError(err, "failed to fetch reviews", span, handler.loki, "GetAllReviewsBySubReviewed", "")
+		handleError(w, span, err)
+		return
 	}
 
 	util.HttpTraceInfo("Successfully fetched all reviews by sub", span, handler.loki, "GetAllReviewsBySubReviewed", "")
 	writeResponse(w, http.StatusOK, response)
 }
+
+// GetReviewPage is the v2 listing of a subject, which returns one page of
+// its reviews at a time.
+func (handler *ReviewHandler) GetReviewPage(w http.ResponseWriter, r *http.Request) {
+	_, span := handler.traceProvider.Tracer(domain.ServiceName).Start(r.Context(), "get-review-page-get")
+	defer func() { span.End() }()
+	subReviewed := mux.Vars(r)["sub-reviewed"]
+	definition, err := handler.parseReviewType(r, mux.Vars(r)["type"])
+	if err != nil {
+		util.HttpTraceError(err, "invalid review type", span, handler.loki, "GetReviewPage", "")
+		handleError(w, span, err)
+		return
+	}
+
+	sortOrder := domain.ReviewSortOrder(r.URL.Query().Get("sort"))
+	if sortOrder != domain.SortUnordered && sortOrder != domain.SortNewest && sortOrder != domain.SortMostHelpful {
+		util.HttpTraceError(errors.New("invalid sort order"), "invalid sort order", span, handler.loki, "GetReviewPage", "")
+		handleError(w, span, domain.NewValidationError("invalid_sort_order", "Invalid sort order"))
+		return
+	}
+
+	filter, err := parseReviewFilter(r)
+	if err != nil {
+		util.HttpTraceError(err, "invalid review filter", span, handler.loki, "GetReviewPage", "")
+		handleError(w, span, err)
+		return
+	}
+
+	page, err := parsePage(r)
+	if err != nil {
+		util.HttpTraceError(err, "invalid page", span, handler.loki, "GetReviewPage", "")
+		handleError(w, span, err)
+		return
+	}
+
+	response, err := handler.reviewService.GetPageBySubReviewed(
+		subReviewed,
+		definition.Type,
+		filter,
+		sortOrder,
+		page,
+		baseLanguage(r.URL.Query().Get("translateTo")),
+		span, handler.loki,
+	)
+	if err != nil {
+		util.HttpTraceError(err, "failed to fetch reviews", span, handler.loki, "GetReviewPage", "")
+		handleError(w, span, err)
+		return
+	}
+
+	util.HttpTraceInfo("Successfully fetched page of reviews by sub", span, handler.loki, "GetReviewPage", "")
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (handler *ReviewHandler) VoteHelpful(w http.ResponseWriter, r *http.Request) {
+	handler.vote(w, r, true)
+}
+
+func (handler *ReviewHandler) VoteNotHelpful(w http.ResponseWriter, r *http.Request) {
+	handler.vote(w, r, false)
+}
+
+func (handler *ReviewHandler) vote(w http.ResponseWriter, r *http.Request, helpful bool) {
+	_, span := handler.traceProvider.Tracer(domain.ServiceName).Start(r.Context(), "vote-review-post")
+	defer func() { span.End() }()
+	reviewPrimitiveId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		util.HttpTraceError(err, "invalid review id", span, handler.loki, "VoteReview", "")
+		handleError(w, span, errInvalidId)
+		return
+	}
+
+	claims, err := getClaims(r)
+	if err != nil {
+		util.HttpTraceError(err, "missing voter", span, handler.loki, "VoteReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	response, err := handler.reviewService.Vote(reviewPrimitiveId, claims.Subject, helpful, span, handler.loki)
+	if err != nil {
+		util.HttpTraceError(err, "failed to vote on review", span, handler.loki, "VoteReview", "")
+		handleError(w, span, err)
+		return
+	}
+
+	util.HttpTraceInfo("Review vote stored successfully", span, handler.loki, "VoteReview", "")
+	setETag(w, response.Version)
+	writeResponse(w, http.StatusOK, response)
+}
+
+// storedReviewType returns the type of the stored review. Deprecated v1
+// requests still name the type; they are marked as such and rejected when the
+// named type is not the stored one. v2 requests must not name it.
+func (handler *ReviewHandler) storedReviewType(w http.ResponseWriter, r *http.Request, id primitive.ObjectID, named string) (application.ReviewTypeDefinition, error) {
+	if named != "" && requestVersion(r) == apiV2 {
+		return application.ReviewTypeDefinition{}, invalidRequest([]domain.FieldError{{Field: "reviewType", Code: "unknown", Message: "reviewType is not allowed"}})
+	}
+	definition, err := handler.reviewService.ReviewTypeOf(id)
+	if err != nil || named == "" {
+		return definition, err
+	}
+
+	deprecated(w)
+	namedDefinition, err := handler.parseReviewType(r, named)
+	if err != nil {
+		return application.ReviewTypeDefinition{}, err
+	}
+	if namedDefinition.Type != definition.Type {
+		return application.ReviewTypeDefinition{}, domain.ErrReviewTypeMismatch
+	}
+	return definition, nil
+}
+
+// parseReviewType resolves a review type the way the API version of the
+// request names it: v1 also takes the numeric value.
+func (handler *ReviewHandler) parseReviewType(r *http.Request, value string) (application.ReviewTypeDefinition, error) {
+	if requestVersion(r) == apiV2 {
+		return handler.reviewService.ReviewTypes().ParseName(value)
+	}
+	return handler.reviewService.ReviewTypes().Parse(value)
+}
+
+// baseLanguage reduces a language tag such as "sr-Latn" to its primary subtag.
+func baseLanguage(tag string) string {
+	return strings.ToLower(strings.SplitN(strings.TrimSpace(tag), "-", 2)[0])
+}
+
+func (handler *ReviewHandler) SearchReviews(w http.ResponseWriter, r *http.Request) {
+	_, span := handler.traceProvider.Tracer(domain.ServiceName).Start(r.Context(), "search-reviews-get")
+	defer func() { span.End() }()
+	subReviewed := mux.Vars(r)["sub-reviewed"]
+	definition, err := handler.parseReviewType(r, mux.Vars(r)["type"])
+	if err != nil {
+		util.HttpTraceError(err, "invalid review type", span, handler.loki, "SearchReviews", "")
+		handleError(w, span, err)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" || len(query) > maxSearchQueryLength {
+		util.HttpTraceError(errors.New("invalid search query"), "invalid search query", span, handler.loki, "SearchReviews", "")
+		handleError(w, span, domain.NewValidationError("invalid_query", "Search query must be between 1 and 200 characters"))
+		return
+	}
+
+	limit := defaultSearchLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxSearchLimit {
+			util.HttpTraceError(errors.New("invalid search limit"), "invalid search limit", span, handler.loki, "SearchReviews", "")
+			handleError(w, span, domain.NewValidationError("invalid_limit", "Invalid search limit"))
+			return
+		}
+	}
+
+	filter, err := parseReviewFilter(r)
+	if err != nil {
+		util.HttpTraceError(err, "invalid review filter", span, handler.loki, "SearchReviews", "")
+		handleError(w, span, err)
+		return
+	}
+
+	response, err := handler.reviewService.Search(subReviewed, definition.Type, query, filter, limit, span, handler.loki)
+	if err != nil {
+		util.HttpTraceError(err, "failed to search reviews", span, handler.loki, "SearchReviews", "")
+		handleError(w, span, err)
+		return
+	}
+
+	util.HttpTraceInfo("Successfully searched reviews by sub", span, handler.loki, "SearchReviews", "")
+	writeResponse(w, http.StatusOK, response)
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// TracingMiddleware starts the span of each request and passes it on in the
+// request context. Errors written by later middlewares then carry its trace
+// id, and the spans of the handlers become its children.
+func TracingMiddleware(traceProvider *sdktrace.TracerProvider) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := r.Method
+			if currentRoute := mux.CurrentRoute(r); currentRoute != nil {
+				if pathTemplate, err := currentRoute.GetPathTemplate(); err == nil {
+					name = routeName(r.Method, pathTemplate)
+				}
+			}
+			ctx, span := traceProvider.Tracer(domain.ServiceName).Start(r.Context(), name, trace.WithSpanKind(trace.SpanKindServer))
+			defer func() { span.End() }()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"net/http"
+	"strings"
+)
+
+const (
+	apiV1 = "v1"
+	apiV2 = "v2"
+)
+
+// Routes registers the routes of a handler below the path prefix of the
+// router it is given.
+type Routes interface {
+	Init(router *mux.Router)
+}
+
+// VersionedRoutes registers different routes for v2 of the API. Handlers
+// whose contract is the same in both versions only implement Routes.
+type VersionedRoutes interface {
+	Routes
+	InitV2(router *mux.Router)
+}
+
+// MountVersions registers v1 of the API under /grade/v1 and again under
+// /grade, which stays an alias of v1, and v2 under /grade/v2. Both v1 prefixes
+// announce the deprecation of v1. The versioned prefixes are mounted first,
+// since the unversioned routes would take the version for a review id.
+func MountVersions(router *mux.Router, deprecation Deprecation, handlers ...Routes) {
+	v1 := router.PathPrefix(domain.GradeV1ContextPath).Subrouter()
+	v2 := router.PathPrefix(domain.GradeV2ContextPath).Subrouter()
+	unversioned := router.PathPrefix(domain.GradeContextPath).Subrouter()
+
+	for _, versionRouter := range []*mux.Router{v1, unversioned} {
+		versionRouter.Use(deprecation.Middleware)
+		for _, handler := range handlers {
+			handler.Init(versionRouter)
+		}
+	}
+	for _, handler := range handlers {
+		if versioned, ok := handler.(VersionedRoutes); ok {
+			versioned.InitV2(v2)
+		} else {
+			handler.Init(v2)
+		}
+	}
+}
+
+// splitVersion returns the API version of a route path template and the
+// template without the version, so rules written for the unversioned paths
+// cover every version. Unversioned templates are v1.
+func splitVersion(pathTemplate string) (string, string) {
+	for _, version := range []string{apiV1, apiV2} {
+		prefix := domain.GradeContextPath + "/" + version
+		if pathTemplate == prefix || strings.HasPrefix(pathTemplate, prefix+"/") {
+			return version, domain.GradeContextPath + strings.TrimPrefix(pathTemplate, prefix)
+		}
+	}
+	return apiV1, pathTemplate
+}
+
+// unversionedRoute returns the template of the matched route without its
+// API version, or false when no route matched.
+func unversionedRoute(r *http.Request) (string, bool) {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "", false
+	}
+	pathTemplate, err := route.GetPathTemplate()
+	if err != nil {
+		return "", false
+	}
+	_, pathTemplate = splitVersion(pathTemplate)
+	return pathTemplate, true
+}
+
+func requestVersion(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return apiV1
+	}
+	pathTemplate, _ := route.GetPathTemplate()
+	version, _ := splitVersion(pathTemplate)
+	return version
+}
@@ -0,0 +1,21 @@
+package dto
+
+import "github.com/mmmajder/zms-devops-grade-service/domain"
+
+type AspectDTO struct {
+	Term         string  `json:"term"`
+	Mentions     int     `json:"mentions"`
+	AverageGrade float32 `json:"averageGrade"`
+}
+
+func FromTermStats(terms []domain.TermStats) []AspectDTO {
+	aspects := make([]AspectDTO, 0, len(terms))
+	for _, term := range terms {
+		aspects = append(aspects, AspectDTO{
+			Term:         term.Term,
+			Mentions:     term.Mentions,
+			AverageGrade: term.AverageGrade,
+		})
+	}
+	return aspects
+}
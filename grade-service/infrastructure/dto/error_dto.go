@@ -1,6 +1,15 @@
 package dto
 
+type FieldErrorDTO struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
 type ErrorResponse struct {
-	Message    string `json:"message"`
-	StatusCode int    `json:"statusCode"`
+	Code       string          `json:"code"`
+	Message    string          `json:"message"`
+	StatusCode int             `json:"statusCode"`
+	Details    []FieldErrorDTO `json:"details,omitempty"`
+	TraceId    string          `json:"traceId,omitempty"`
 }
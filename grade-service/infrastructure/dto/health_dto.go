@@ -0,0 +1,12 @@
+package dto
+
+type ComponentHealthDTO struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ReadinessDTO struct {
+	Status     string                        `json:"status"`
+	Components map[string]ComponentHealthDTO `json:"components"`
+}
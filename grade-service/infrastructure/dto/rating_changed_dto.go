@@ -1,6 +1,9 @@
 package dto
 
+// RatingChangedDTO carries the subject's rating version; consumers keep the
+// rating with the highest version they have seen.
 type RatingChangedDTO struct {
-	Id     string  `json:"id"`
-	Rating float32 `json:"rating"`
+	Id      string  `json:"id"`
+	Rating  float32 `json:"rating"`
+	Version int64   `json:"version"`
 }
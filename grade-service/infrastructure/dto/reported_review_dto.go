@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"sort"
+	"time"
+)
+
+type ReasonCountDTO struct {
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+}
+
+type ReportedReviewDTO struct {
+	Review         ReviewDTO        `json:"review"`
+	Status         string           `json:"status"`
+	Flagged        bool             `json:"flagged"`
+	TotalReports   int              `json:"totalReports"`
+	Reasons        []ReasonCountDTO `json:"reasons"`
+	LastReportedAt time.Time        `json:"lastReportedAt"`
+}
+
+func FromReportedReview(reportedReview *domain.ReportedReview, review *domain.Review) ReportedReviewDTO {
+	reasons := make([]ReasonCountDTO, 0, len(reportedReview.Reasons))
+	for _, reason := range reportedReview.Reasons {
+		reasons = append(reasons, ReasonCountDTO{
+			Reason: string(reason.Reason),
+			Count:  reason.Count,
+		})
+	}
+
+	sort.SliceStable(reasons, func(i, j int) bool {
+		return reasons[i].Count > reasons[j].Count
+	})
+
+	status := review.Status
+	if status == "" {
+		status = domain.StatusPublished
+	}
+
+	return ReportedReviewDTO{
+		Review:         FromReview(review),
+		Status:         string(status),
+		Flagged:        review.Flagged,
+		TotalReports:   reportedReview.TotalReports,
+		Reasons:        reasons,
+		LastReportedAt: reportedReview.LastReportedAt,
+	}
+}
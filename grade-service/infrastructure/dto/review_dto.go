@@ -13,6 +13,31 @@ type ReviewDTO struct {
 	SubReviewer        string             `json:"subReviewer"`
 	FullName           string             `json:"fullName"`
 	DateOfModification time.Time          `json:"dateOfModification"`
+	HelpfulCount       int                `json:"helpfulCount"`
+	NotHelpfulCount    int                `json:"notHelpfulCount"`
+	Attachments        []AttachmentDTO    `json:"attachments"`
+	Language           string             `json:"language"`
+	TranslatedComment  string             `json:"translatedComment,omitempty"`
+	Sentiment          float64            `json:"sentiment"`
+	SentimentMismatch  bool               `json:"sentimentMismatch"`
+	Version            int64              `json:"version"`
+}
+
+type AttachmentDTO struct {
+	Id          string `json:"id"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	Url         string `json:"url"`
+}
+
+func FromAttachment(attachment domain.Attachment) AttachmentDTO {
+	return AttachmentDTO{
+		Id:          attachment.Id,
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+	}
 }
 
 func FromReviews(reviews []*domain.Review) *[]ReviewDTO {
@@ -32,6 +57,16 @@ func FromReview(review *domain.Review) ReviewDTO {
 		SubReviewer:        review.SubReviewer,
 		FullName:           review.ReviewerFullName,
 		DateOfModification: review.DateOfModification,
+		HelpfulCount:       review.HelpfulCount,
+		NotHelpfulCount:    review.NotHelpfulCount,
+		Attachments:        make([]AttachmentDTO, 0, len(review.Attachments)),
+		Language:           review.Language,
+		Sentiment:          review.Sentiment.Score,
+		SentimentMismatch:  review.Sentiment.Mismatch,
+		Version:            review.Version,
+	}
+	for _, attachment := range review.Attachments {
+		dto.Attachments = append(dto.Attachments, FromAttachment(attachment))
 	}
 	return dto
 }
@@ -15,9 +15,32 @@ func GetDefaultNumberOfStars() []NumberOfStars {
 	}
 }
 
+// ReviewReportDTO summarizes all published reviews of a subject.
+// MatchingReviews counts those that match the filter of the listing.
 type ReviewReportDTO struct {
-	TotalReviews  int             `json:"totalReviews"`
-	AverageRating float32         `json:"averageRating"`
-	NumberOfStars []NumberOfStars `json:"numberOfStars"`
-	Reviews       []ReviewDTO     `json:"reviews"`
+	TotalReviews       int                      `json:"totalReviews"`
+	MatchingReviews    int                      `json:"matchingReviews"`
+	AverageRating      float32                  `json:"averageRating"`
+	RatingVersion      int64                    `json:"ratingVersion"`
+	NumberOfStars      []NumberOfStars          `json:"numberOfStars"`
+	Reviews            []ReviewDTO              `json:"reviews"`
+	Sentiment          SentimentDistributionDTO `json:"sentiment"`
+	TopPositiveAspects []AspectDTO              `json:"topPositiveAspects"`
+	TopNegativeAspects []AspectDTO              `json:"topNegativeAspects"`
+}
+
+// ReviewReportPageDTO is the v2 listing of a subject: its report with one
+// page of the reviews that match the filter.
+type ReviewReportPageDTO struct {
+	ReviewReportDTO
+	Page       int `json:"page"`
+	PageSize   int `json:"pageSize"`
+	TotalPages int `json:"totalPages"`
+}
+
+type SentimentDistributionDTO struct {
+	Positive   int `json:"positive"`
+	Neutral    int `json:"neutral"`
+	Negative   int `json:"negative"`
+	Mismatched int `json:"mismatched"`
 }
@@ -0,0 +1,18 @@
+package dto
+
+type SnippetFragmentDTO struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match"`
+}
+
+type ReviewSearchResultDTO struct {
+	Review  ReviewDTO            `json:"review"`
+	Score   float64              `json:"score"`
+	Snippet []SnippetFragmentDTO `json:"snippet"`
+}
+
+type ReviewSearchDTO struct {
+	Query        string                  `json:"query"`
+	TotalResults int                     `json:"totalResults"`
+	Results      []ReviewSearchResultDTO `json:"results"`
+}
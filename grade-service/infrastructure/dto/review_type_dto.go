@@ -0,0 +1,8 @@
+package dto
+
+type ReviewTypeDTO struct {
+	Id           int      `json:"id"`
+	Name         string   `json:"name"`
+	ReviewerRole string   `json:"reviewerRole"`
+	Criteria     []string `json:"criteria"`
+}
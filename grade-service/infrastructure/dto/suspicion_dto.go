@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"time"
+)
+
+type SuspicionDTO struct {
+	Id             string    `json:"id"`
+	Kind           string    `json:"kind"`
+	SubReviewed    string    `json:"subReviewed"`
+	Type           int       `json:"type"`
+	Reviewer       string    `json:"reviewer,omitempty"`
+	ReviewIds      []string  `json:"reviewIds"`
+	Score          float64   `json:"score"`
+	Details        string    `json:"details"`
+	DateOfCreation time.Time `json:"dateOfCreation"`
+	LastDetectedAt time.Time `json:"lastDetectedAt"`
+}
+
+func FromSuspicions(suspicions []*domain.Suspicion) []SuspicionDTO {
+	suspicionDTOs := make([]SuspicionDTO, 0, len(suspicions))
+	for _, suspicion := range suspicions {
+		reviewIds := make([]string, 0, len(suspicion.ReviewIds))
+		for _, id := range suspicion.ReviewIds {
+			reviewIds = append(reviewIds, id.Hex())
+		}
+		suspicionDTOs = append(suspicionDTOs, SuspicionDTO{
+			Id:             suspicion.Id.Hex(),
+			Kind:           string(suspicion.Kind),
+			SubReviewed:    suspicion.SubReviewed,
+			Type:           int(suspicion.Type),
+			Reviewer:       suspicion.Reviewer,
+			ReviewIds:      reviewIds,
+			Score:          suspicion.Score,
+			Details:        suspicion.Details,
+			DateOfCreation: suspicion.DateOfCreation,
+			LastDetectedAt: suspicion.LastDetectedAt,
+		})
+	}
+	return suspicionDTOs
+}
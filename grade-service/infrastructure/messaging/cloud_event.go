@@ -0,0 +1,67 @@
+package messaging
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"time"
+)
+
+const (
+	cloudEventsVersion     = "1.0"
+	cloudEventsContentType = "application/cloudevents+json"
+	eventSource            = "/" + domain.ServiceName
+)
+
+// CloudEvent is the structured mode JSON envelope of CloudEvents 1.0.
+type CloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	Id              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            time.Time   `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	DataSchema      string      `json:"dataschema,omitempty"`
+	Data            interface{} `json:"data"`
+}
+
+func newCloudEvent(event domain.Event) (CloudEvent, error) {
+	id, err := newEventId()
+	if err != nil {
+		return CloudEvent{}, err
+	}
+	return CloudEvent{
+		SpecVersion:     cloudEventsVersion,
+		Id:              id,
+		Source:          eventSource,
+		Type:            event.Type,
+		Subject:         event.Subject,
+		Time:            time.Now().UTC(),
+		DataContentType: domain.JsonContentType,
+		DataSchema:      event.DataSchema,
+		Data:            event.Data,
+	}, nil
+}
+
+// newEventId returns a random (version 4) UUID.
+func newEventId() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16]), nil
+}
+
+// traceHeaders returns the W3C trace context of ctx, traceparent and
+// tracestate, as set by the global propagator.
+func traceHeaders(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
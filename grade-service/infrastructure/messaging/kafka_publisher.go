@@ -0,0 +1,86 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/metrics"
+	"time"
+)
+
+const deliveryTimeout = 4 * time.Second
+
+type KafkaPublisher struct {
+	producer        *kafka.Producer
+	deliveryTimeout time.Duration
+}
+
+func NewKafkaPublisher(producer *kafka.Producer) domain.EventPublisher {
+	return NewKafkaPublisherWithTimeout(producer, deliveryTimeout)
+}
+
+// NewKafkaPublisherWithTimeout waits at most timeout for each delivery report.
+func NewKafkaPublisherWithTimeout(producer *kafka.Producer, timeout time.Duration) domain.EventPublisher {
+	return &KafkaPublisher{
+		producer:        producer,
+		deliveryTimeout: timeout,
+	}
+}
+
+// Publish sends the event keyed by event.Key, with the trace context in the
+// message headers, and waits for its delivery report to count the delivery
+// per topic. It gives up after the delivery timeout or when ctx is done; the
+// message may still be delivered later.
+func (publisher *KafkaPublisher) Publish(ctx context.Context, event domain.Event) error {
+	topic := event.Topic
+	cloudEvent, err := newCloudEvent(event)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return err
+	}
+
+	headers := []kafka.Header{{Key: "content-type", Value: []byte(cloudEventsContentType)}}
+	for key, header := range traceHeaders(ctx) {
+		headers = append(headers, kafka.Header{Key: key, Value: []byte(header)})
+	}
+
+	deliveries := make(chan kafka.Event, 1)
+	err = publisher.producer.Produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            []byte(event.Key),
+		Value:          value,
+		Headers:        headers,
+	}, deliveries)
+	if err != nil {
+		metrics.KafkaMessages.WithLabelValues(topic, metrics.Failure).Inc()
+		return err
+	}
+
+	timer := time.NewTimer(publisher.deliveryTimeout)
+	defer timer.Stop()
+	select {
+	case event := <-deliveries:
+		delivered, ok := event.(*kafka.Message)
+		if !ok {
+			metrics.KafkaMessages.WithLabelValues(topic, metrics.Failure).Inc()
+			return errors.New("unexpected delivery report for " + topic + ": " + event.String())
+		}
+		if delivered.TopicPartition.Error != nil {
+			metrics.KafkaMessages.WithLabelValues(topic, metrics.Failure).Inc()
+			return delivered.TopicPartition.Error
+		}
+		metrics.KafkaMessages.WithLabelValues(topic, metrics.Success).Inc()
+		return nil
+	case <-timer.C:
+		metrics.KafkaMessages.WithLabelValues(topic, metrics.Failure).Inc()
+		return errors.New("message to " + topic + " was not delivered within " + publisher.deliveryTimeout.String())
+	case <-ctx.Done():
+		metrics.KafkaMessages.WithLabelValues(topic, metrics.Failure).Inc()
+		return ctx.Err()
+	}
+}
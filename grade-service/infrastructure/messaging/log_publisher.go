@@ -0,0 +1,29 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"log"
+)
+
+// LogPublisher writes events to the service log instead of a broker, for
+// local runs without Kafka.
+type LogPublisher struct{}
+
+func NewLogPublisher() domain.EventPublisher {
+	return &LogPublisher{}
+}
+
+func (publisher *LogPublisher) Publish(ctx context.Context, event domain.Event) error {
+	cloudEvent, err := newCloudEvent(event)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return err
+	}
+	log.Printf("event published to %s with key %s: %s", event.Topic, event.Key, value)
+	return nil
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	namespace = "grade"
+
+	Success = "success"
+	Failure = "failure"
+)
+
+var HttpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "http_requests_total",
+	Help:      "HTTP requests by route, method and status.",
+}, []string{"route", "method", "status"})
+
+var HttpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "http_request_duration_seconds",
+	Help:      "HTTP request latency by route, method and status.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"route", "method", "status"})
+
+var RateLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "rate_limit_rejections_total",
+	Help:      "Requests rejected by the rate limiter, by route and the key that ran out.",
+}, []string{"route", "key"})
+
+var MongoOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "mongo_operation_duration_seconds",
+	Help:      "MongoDB operation latency by collection and operation.",
+	Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+}, []string{"collection", "operation"})
+
+var KafkaMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "kafka_messages_total",
+	Help:      "Kafka messages produced by topic and result.",
+}, []string{"topic", "result"})
+
+var BookingRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "booking_request_duration_seconds",
+	Help:      "Booking service gRPC latency by method.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method"})
+
+var BookingRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "booking_request_errors_total",
+	Help:      "Failed booking service gRPC calls by method and status code.",
+}, []string{"method", "code"})
+
+var ReviewsCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "reviews_created_total",
+	Help:      "Reviews created by review type.",
+}, []string{"type"})
+
+var ReviewsDeleted = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "reviews_deleted_total",
+	Help:      "Reviews deleted by review type.",
+}, []string{"type"})
+
+var ReviewVotes = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "review_votes_total",
+	Help:      "Helpfulness votes by vote.",
+}, []string{"vote"})
+
+var ReviewReports = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "review_reports_total",
+	Help:      "Abuse reports by reason.",
+}, []string{"reason"})
@@ -0,0 +1,45 @@
+package persistence
+
+import (
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
+)
+
+const (
+	ATTACHMENT_BUCKET = "attachments"
+)
+
+type GridFSBlobStore struct {
+	bucket *gridfs.Bucket
+}
+
+func NewGridFSBlobStore(client *mongo.Client) (domain.BlobStore, error) {
+	bucket, err := gridfs.NewBucket(client.Database(DATABASE), options.GridFSBucket().SetName(ATTACHMENT_BUCKET))
+	if err != nil {
+		return nil, err
+	}
+	return &GridFSBlobStore{
+		bucket: bucket,
+	}, nil
+}
+
+func (store *GridFSBlobStore) Put(key string, contentType string, content io.Reader) error {
+	opts := options.GridFSUpload().SetMetadata(bson.M{"content_type": contentType})
+	return store.bucket.UploadFromStreamWithID(key, key, content, opts)
+}
+
+func (store *GridFSBlobStore) Get(key string) (io.ReadCloser, error) {
+	return store.bucket.OpenDownloadStream(key)
+}
+
+func (store *GridFSBlobStore) Delete(key string) error {
+	err := store.bucket.Delete(key)
+	if err == gridfs.ErrFileNotFound {
+		return nil
+	}
+	return err
+}
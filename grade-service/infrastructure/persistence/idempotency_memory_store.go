@@ -0,0 +1,65 @@
+package persistence
+
+import (
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"sync"
+	"time"
+)
+
+// IdempotencyMemoryStore keeps idempotency keys in process, so a retry that
+// reaches another replica is handled again.
+type IdempotencyMemoryStore struct {
+	mutex     sync.Mutex
+	requests  map[string]domain.IdempotentRequest
+	lastSweep time.Time
+}
+
+func NewIdempotencyMemoryStore() domain.IdempotencyStore {
+	return &IdempotencyMemoryStore{
+		requests:  make(map[string]domain.IdempotentRequest),
+		lastSweep: time.Now(),
+	}
+}
+
+func (store *IdempotencyMemoryStore) Reserve(request *domain.IdempotentRequest) (*domain.IdempotentRequest, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	store.sweep(now)
+	if stored, ok := store.requests[request.Key]; ok && stored.ExpiresAt.After(now) {
+		return &stored, nil
+	}
+	store.requests[request.Key] = *request
+	return nil, nil
+}
+
+func (store *IdempotencyMemoryStore) Complete(key string, response domain.IdempotentResponse, expiresAt time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if stored, ok := store.requests[key]; ok {
+		stored.Response = &response
+		stored.ExpiresAt = expiresAt
+		store.requests[key] = stored
+	}
+	return nil
+}
+
+func (store *IdempotencyMemoryStore) Release(key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.requests, key)
+	return nil
+}
+
+func (store *IdempotencyMemoryStore) sweep(now time.Time) {
+	if now.Sub(store.lastSweep) < sweepInterval {
+		return
+	}
+	store.lastSweep = now
+	for key, request := range store.requests {
+		if !request.ExpiresAt.After(now) {
+			delete(store.requests, key)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const (
+	IDEMPOTENCY_COLLECTION = "idempotency_keys"
+)
+
+// IdempotencyMongoDBStore shares idempotency keys between replicas. Mongo
+// removes expired keys only about once a minute, so Reserve takes over keys
+// that expired but are still stored.
+type IdempotencyMongoDBStore struct {
+	requests *mongo.Collection
+}
+
+func NewIdempotencyMongoDBStore(client *mongo.Client) domain.IdempotencyStore {
+	requests := client.Database(DATABASE).Collection(IDEMPOTENCY_COLLECTION)
+	_, err := requests.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		log.Printf("failed to create idempotency index: %v", err)
+	}
+	return &IdempotencyMongoDBStore{
+		requests: requests,
+	}
+}
+
+func (store *IdempotencyMongoDBStore) Reserve(request *domain.IdempotentRequest) (*domain.IdempotentRequest, error) {
+	for attempt := 0; attempt < 3; attempt++ {
+		_, err := store.requests.InsertOne(context.TODO(), request)
+		if err == nil {
+			return nil, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		var stored domain.IdempotentRequest
+		err = store.requests.FindOne(context.TODO(), bson.M{"_id": request.Key}).Decode(&stored)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// The stored key expired between the insert and the lookup.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if stored.ExpiresAt.After(time.Now()) {
+			return &stored, nil
+		}
+		result, err := store.requests.ReplaceOne(context.TODO(), bson.M{"_id": request.Key, "expires_at": stored.ExpiresAt}, request)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 1 {
+			return nil, nil
+		}
+		// Another replica took over the expired key first.
+	}
+	return nil, errors.New("could not reserve idempotency key " + request.Key)
+}
+
+func (store *IdempotencyMongoDBStore) Complete(key string, response domain.IdempotentResponse, expiresAt time.Time) error {
+	_, err := store.requests.UpdateByID(context.TODO(), key, bson.M{"$set": bson.M{"response": response, "expires_at": expiresAt}})
+	return err
+}
+
+func (store *IdempotencyMongoDBStore) Release(key string) error {
+	_, err := store.requests.DeleteOne(context.TODO(), bson.M{"_id": key})
+	return err
+}
@@ -0,0 +1,14 @@
+package persistence
+
+import (
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/metrics"
+	"time"
+)
+
+// observe starts timing a Mongo operation; defer the returned func.
+func observe(collection string, operation string) func() {
+	start := time.Now()
+	return func() {
+		metrics.MongoOperationDuration.WithLabelValues(collection, operation).Observe(time.Since(start).Seconds())
+	}
+}
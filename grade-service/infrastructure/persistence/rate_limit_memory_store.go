@@ -0,0 +1,85 @@
+package persistence
+
+import (
+	"fmt"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	period    time.Duration
+}
+
+// RateLimitMemoryStore keeps token buckets in process, so each replica
+// enforces its own limits.
+type RateLimitMemoryStore struct {
+	mutex     sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func NewRateLimitMemoryStore() domain.RateLimitStore {
+	return &RateLimitMemoryStore{
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (store *RateLimitMemoryStore) Take(key string, limit domain.RateLimit) (bool, time.Duration, error) {
+	if !limit.IsValid() {
+		return false, 0, invalidRateLimit(limit)
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	store.sweep(now)
+
+	capacity := float64(limit.Requests)
+	perSecond := capacity / limit.Period.Seconds()
+	bucket, ok := store.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: capacity, updatedAt: now}
+		store.buckets[key] = bucket
+	}
+	bucket.tokens = min(capacity, bucket.tokens+now.Sub(bucket.updatedAt).Seconds()*perSecond)
+	bucket.updatedAt = now
+	bucket.period = limit.Period
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second)), nil
+	}
+	bucket.tokens--
+	return true, 0, nil
+}
+
+func (store *RateLimitMemoryStore) Refund(key string, limit domain.RateLimit) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if bucket, ok := store.buckets[key]; ok {
+		bucket.tokens = min(float64(limit.Requests), bucket.tokens+1)
+	}
+	return nil
+}
+
+// sweep drops buckets idle for a whole period; they would be full again.
+func (store *RateLimitMemoryStore) sweep(now time.Time) {
+	if now.Sub(store.lastSweep) < sweepInterval {
+		return
+	}
+	store.lastSweep = now
+	for key, bucket := range store.buckets {
+		if now.Sub(bucket.updatedAt) > bucket.period {
+			delete(store.buckets, key)
+		}
+	}
+}
+
+func invalidRateLimit(limit domain.RateLimit) error {
+	return fmt.Errorf("invalid rate limit of %d requests per %s", limit.Requests, limit.Period)
+}
@@ -0,0 +1,88 @@
+package persistence
+
+import (
+	"context"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const (
+	RATE_LIMIT_COLLECTION = "rate_limits"
+)
+
+type rateLimitBucket struct {
+	Tokens  float64 `bson:"tokens"`
+	Allowed bool    `bson:"allowed"`
+}
+
+// RateLimitMongoDBStore shares token buckets between replicas. Each Take is
+// a single pipeline update, so concurrent requests cannot spend a token twice.
+type RateLimitMongoDBStore struct {
+	buckets *mongo.Collection
+}
+
+func NewRateLimitMongoDBStore(client *mongo.Client) domain.RateLimitStore {
+	buckets := client.Database(DATABASE).Collection(RATE_LIMIT_COLLECTION)
+	_, err := buckets.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		log.Printf("failed to create rate limit index: %v", err)
+	}
+	return &RateLimitMongoDBStore{
+		buckets: buckets,
+	}
+}
+
+func (store *RateLimitMongoDBStore) Take(key string, limit domain.RateLimit) (bool, time.Duration, error) {
+	if !limit.IsValid() {
+		return false, 0, invalidRateLimit(limit)
+	}
+	now := time.Now()
+	capacity := float64(limit.Requests)
+	perMillisecond := capacity / float64(limit.Period.Milliseconds())
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"tokens": bson.M{"$min": bson.A{capacity, bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$tokens", capacity}},
+				bson.M{"$multiply": bson.A{
+					bson.M{"$subtract": bson.A{now, bson.M{"$ifNull": bson.A{"$updated_at", now}}}},
+					perMillisecond,
+				}},
+			}}}},
+			"updated_at": now,
+			"expires_at": now.Add(limit.Period),
+		}}},
+		{{Key: "$set", Value: bson.M{"allowed": bson.M{"$gte": bson.A{"$tokens", 1}}}}},
+		{{Key: "$set", Value: bson.M{"tokens": bson.M{"$cond": bson.A{"$allowed", bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}}}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var bucket rateLimitBucket
+	err := store.buckets.FindOneAndUpdate(context.TODO(), bson.M{"_id": key}, pipeline, opts).Decode(&bucket)
+	if mongo.IsDuplicateKeyError(err) {
+		// Another replica created the bucket between our lookup and insert.
+		err = store.buckets.FindOneAndUpdate(context.TODO(), bson.M{"_id": key}, pipeline, opts).Decode(&bucket)
+	}
+	if err != nil {
+		return false, 0, err
+	}
+
+	if !bucket.Allowed {
+		return false, time.Duration((1 - bucket.Tokens) / perMillisecond * float64(time.Millisecond)), nil
+	}
+	return true, 0, nil
+}
+
+func (store *RateLimitMongoDBStore) Refund(key string, limit domain.RateLimit) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"tokens": bson.M{"$min": bson.A{float64(limit.Requests), bson.M{"$add": bson.A{"$tokens", 1}}}}}}},
+	}
+	_, err := store.buckets.UpdateOne(context.TODO(), bson.M{"_id": key}, pipeline)
+	return err
+}
@@ -0,0 +1,67 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const (
+	RATING_VERSION_COLLECTION = "rating_versions"
+)
+
+type RatingVersionMongoDBStore struct {
+	versions *mongo.Collection
+}
+
+func NewRatingVersionMongoDBStore(client *mongo.Client) domain.RatingVersionStore {
+	versions := client.Database(DATABASE).Collection(RATING_VERSION_COLLECTION)
+	_, err := versions.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "sub_reviewed", Value: 1}, {Key: "type", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("failed to create rating version index: %v", err)
+	}
+	return &RatingVersionMongoDBStore{
+		versions: versions,
+	}
+}
+
+// Next relies on $inc being atomic, so concurrent replicas never hand out the
+// same version.
+func (store *RatingVersionMongoDBStore) Next(subReviewed string, reviewType domain.ReviewType) (int64, error) {
+	filter := bson.M{"sub_reviewed": subReviewed, "type": reviewType}
+	update := bson.M{
+		"$inc": bson.M{"version": 1},
+		"$set": bson.M{"date_of_modification": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var version domain.RatingVersion
+	if err := store.versions.FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(&version); err != nil {
+		return 0, err
+	}
+	return version.Version, nil
+}
+
+func (store *RatingVersionMongoDBStore) Get(subReviewed string, reviewType domain.ReviewType) (int64, error) {
+	filter := bson.M{"sub_reviewed": subReviewed, "type": reviewType}
+	var version domain.RatingVersion
+	err := store.versions.FindOne(context.TODO(), filter).Decode(&version)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return version.Version, nil
+}
+
+func (store *RatingVersionMongoDBStore) DeleteAll() {
+	store.versions.DeleteMany(context.TODO(), bson.D{{}})
+}
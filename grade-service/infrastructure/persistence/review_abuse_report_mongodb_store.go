@@ -0,0 +1,101 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+)
+
+const (
+	REPORT_COLLECTION = "review_reports"
+)
+
+type ReviewAbuseReportMongoDBStore struct {
+	reports *mongo.Collection
+}
+
+func NewReviewAbuseReportMongoDBStore(client *mongo.Client) domain.ReviewAbuseReportStore {
+	reports := client.Database(DATABASE).Collection(REPORT_COLLECTION)
+	// The unique index used to cover resolved reports too, which kept anyone
+	// from reporting a review again after a moderator restored it.
+	if _, err := reports.Indexes().DropOne(context.TODO(), "review_id_1_reporter_1"); err != nil && !isIndexNotFound(err) {
+		log.Printf("failed to drop review report index: %v", err)
+	}
+	_, err := reports.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys: bson.D{{Key: "review_id", Value: 1}, {Key: "reporter", Value: 1}},
+		Options: options.Index().
+			SetName("review_id_1_reporter_1_unresolved").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"resolved": false}),
+	})
+	if err != nil {
+		log.Printf("failed to create review report index: %v", err)
+	}
+	return &ReviewAbuseReportMongoDBStore{
+		reports: reports,
+	}
+}
+
+func (store *ReviewAbuseReportMongoDBStore) Insert(report *domain.ReviewAbuseReport) error {
+	report.Id = primitive.NewObjectID()
+	_, err := store.reports.InsertOne(context.TODO(), report)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrAlreadyReported
+	}
+	return err
+}
+
+func (store *ReviewAbuseReportMongoDBStore) CountByReview(reviewId primitive.ObjectID) (int, error) {
+	count, err := store.reports.CountDocuments(context.TODO(), bson.M{"review_id": reviewId, "resolved": bson.M{"$ne": true}})
+	return int(count), err
+}
+
+func (store *ReviewAbuseReportMongoDBStore) Resolve(reviewId primitive.ObjectID) error {
+	_, err := store.reports.UpdateMany(context.TODO(), bson.M{"review_id": reviewId}, bson.M{"$set": bson.M{"resolved": true}})
+	return err
+}
+
+func (store *ReviewAbuseReportMongoDBStore) GetReportedReviews() ([]*domain.ReportedReview, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"resolved": bson.M{"$ne": true}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":              bson.M{"review_id": "$review_id", "reason": "$reason"},
+			"count":            bson.M{"$sum": 1},
+			"last_reported_at": bson.M{"$max": "$date_of_creation"},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":              "$_id.review_id",
+			"total_reports":    bson.M{"$sum": "$count"},
+			"reasons":          bson.M{"$push": bson.M{"reason": "$_id.reason", "count": "$count"}},
+			"last_reported_at": bson.M{"$max": "$last_reported_at"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "total_reports", Value: -1}, {Key: "last_reported_at", Value: -1}}}},
+	}
+	cursor, err := store.reports.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var reportedReviews []*domain.ReportedReview
+	if err := cursor.All(context.TODO(), &reportedReviews); err != nil {
+		return nil, err
+	}
+	return reportedReviews, nil
+}
+
+func (store *ReviewAbuseReportMongoDBStore) DeleteAll() {
+	store.reports.DeleteMany(context.TODO(), bson.D{{}})
+}
+
+// isIndexNotFound tells whether err is Mongo reporting a missing index or
+// collection, which is what dropping an index that was never created returns.
+func isIndexNotFound(err error) bool {
+	var commandErr mongo.CommandError
+	return errors.As(err, &commandErr) && (commandErr.Code == 27 || commandErr.Code == 26)
+}
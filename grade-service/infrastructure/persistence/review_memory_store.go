@@ -0,0 +1,260 @@
+package persistence
+
+import (
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ReviewMemoryStore keeps reviews in process. It backs tests and local runs
+// without Mongo, and returns copies so callers can not change stored reviews.
+type ReviewMemoryStore struct {
+	mutex   sync.RWMutex
+	reviews map[primitive.ObjectID]domain.Review
+}
+
+func NewReviewMemoryStore() *ReviewMemoryStore {
+	return &ReviewMemoryStore{
+		reviews: make(map[primitive.ObjectID]domain.Review),
+	}
+}
+
+func (store *ReviewMemoryStore) Get(id primitive.ObjectID) (*domain.Review, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	review, ok := store.reviews[id]
+	if !ok {
+		return nil, domain.ErrReviewNotFound
+	}
+	review = copyReview(review)
+	return &review, nil
+}
+
+func (store *ReviewMemoryStore) GetAllBySubReviewed(subReviewed string, reviewType domain.ReviewType) ([]*domain.Review, error) {
+	return store.find(func(review domain.Review) bool {
+		return review.SubReviewed == subReviewed && review.Type == reviewType && review.Status != domain.StatusPendingModeration
+	}), nil
+}
+
+func (store *ReviewMemoryStore) GetMatchingBySubReviewed(subReviewed string, reviewType domain.ReviewType, filter domain.ReviewFilter) ([]*domain.Review, error) {
+	return store.find(func(review domain.Review) bool {
+		return review.SubReviewed == subReviewed && review.Type == reviewType && review.Status != domain.StatusPendingModeration && filter.Matches(&review)
+	}), nil
+}
+
+func (store *ReviewMemoryStore) GetAllBySubReviewers(subReviewers []string) ([]*domain.Review, error) {
+	reviewers := make(map[string]struct{}, len(subReviewers))
+	for _, subReviewer := range subReviewers {
+		reviewers[subReviewer] = struct{}{}
+	}
+	return store.find(func(review domain.Review) bool {
+		_, ok := reviewers[review.SubReviewer]
+		return ok
+	}), nil
+}
+
+func (store *ReviewMemoryStore) Insert(review *domain.Review) (primitive.ObjectID, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if review.Id.IsZero() {
+		review.Id = primitive.NewObjectID()
+	}
+	review.Version = 1
+	store.reviews[review.Id] = copyReview(*review)
+	return review.Id, nil
+}
+
+func (store *ReviewMemoryStore) Delete(id primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if _, ok := store.reviews[id]; !ok {
+		return domain.ErrReviewNotFound
+	}
+	delete(store.reviews, id)
+	return nil
+}
+
+func (store *ReviewMemoryStore) DeleteAll() {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.reviews = make(map[primitive.ObjectID]domain.Review)
+}
+
+func (store *ReviewMemoryStore) Update(id primitive.ObjectID, comment string, grade float32, language string, sentiment domain.Sentiment, versions []int64) (*domain.Review, error) {
+	return store.updateContent(id, func(review *domain.Review) error {
+		if versions != nil && !containsVersion(versions, review.Version) {
+			return domain.ErrVersionMismatch
+		}
+		review.Comment = comment
+		review.Grade = grade
+		review.Language = language
+		review.Sentiment = sentiment
+		review.DateOfModification = time.Now()
+		return nil
+	})
+}
+
+func (store *ReviewMemoryStore) SetVoteCounts(id primitive.ObjectID, helpful int, notHelpful int) (*domain.Review, error) {
+	return store.update(id, func(review *domain.Review) error {
+		review.HelpfulCount = helpful
+		review.NotHelpfulCount = notHelpful
+		return nil
+	})
+}
+
+func (store *ReviewMemoryStore) UpdateStatus(id primitive.ObjectID, status domain.ReviewStatus) (*domain.Review, error) {
+	return store.update(id, func(review *domain.Review) error {
+		review.Status = status
+		return nil
+	})
+}
+
+func (store *ReviewMemoryStore) Flag(id primitive.ObjectID) (*domain.Review, error) {
+	return store.update(id, func(review *domain.Review) error {
+		review.Flagged = true
+		return nil
+	})
+}
+
+func (store *ReviewMemoryStore) AddAttachment(id primitive.ObjectID, attachment domain.Attachment, maxCount int) (*domain.Review, error) {
+	return store.updateContent(id, func(review *domain.Review) error {
+		if len(review.Attachments) >= maxCount {
+			return domain.ErrTooManyAttachments
+		}
+		review.Attachments = append(review.Attachments, attachment)
+		return nil
+	})
+}
+
+func (store *ReviewMemoryStore) GetByAttachment(attachmentId string) (*domain.Review, error) {
+	reviews := store.find(func(review domain.Review) bool {
+		for _, attachment := range review.Attachments {
+			if attachment.Id == attachmentId {
+				return true
+			}
+		}
+		return false
+	})
+	if len(reviews) == 0 {
+		return nil, domain.ErrAttachmentNotFound
+	}
+	return reviews[0], nil
+}
+
+// Search scores reviews by how many query words their comment contains,
+// a rough stand-in for the Mongo text index.
+func (store *ReviewMemoryStore) Search(subReviewed string, reviewType domain.ReviewType, query string, filter domain.ReviewFilter, limit int) ([]*domain.ReviewSearchResult, int, error) {
+	terms := words(query)
+	reviews, _ := store.GetAllBySubReviewed(subReviewed, reviewType)
+
+	results := make([]*domain.ReviewSearchResult, 0)
+	for _, review := range reviews {
+		if !filter.Matches(review) {
+			continue
+		}
+		comment := make(map[string]struct{})
+		for _, word := range words(review.Comment) {
+			comment[word] = struct{}{}
+		}
+		var score float64
+		for _, term := range terms {
+			if _, ok := comment[term]; ok {
+				score++
+			}
+		}
+		if score > 0 {
+			results = append(results, &domain.ReviewSearchResult{Review: review, Score: score})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	total := len(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, total, nil
+}
+
+func (store *ReviewMemoryStore) GetSubjects() ([]domain.ReviewSubject, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	seen := make(map[domain.ReviewSubject]struct{})
+	subjects := make([]domain.ReviewSubject, 0)
+	for _, review := range store.reviews {
+		subject := domain.ReviewSubject{SubReviewed: review.SubReviewed, Type: review.Type}
+		if _, ok := seen[subject]; ok {
+			continue
+		}
+		seen[subject] = struct{}{}
+		subjects = append(subjects, subject)
+	}
+	return subjects, nil
+}
+
+func (store *ReviewMemoryStore) find(matches func(review domain.Review) bool) []*domain.Review {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	reviews := make([]*domain.Review, 0)
+	for _, review := range store.reviews {
+		if matches(review) {
+			found := copyReview(review)
+			reviews = append(reviews, &found)
+		}
+	}
+	sort.Slice(reviews, func(i, j int) bool {
+		return reviews[i].Id.Hex() < reviews[j].Id.Hex()
+	})
+	return reviews
+}
+
+func (store *ReviewMemoryStore) update(id primitive.ObjectID, change func(review *domain.Review) error) (*domain.Review, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	review, ok := store.reviews[id]
+	if !ok {
+		return nil, domain.ErrReviewNotFound
+	}
+	review = copyReview(review)
+	if err := change(&review); err != nil {
+		return nil, err
+	}
+	store.reviews[id] = review
+	updated := copyReview(review)
+	return &updated, nil
+}
+
+// updateContent changes the content of the review and increments its version.
+func (store *ReviewMemoryStore) updateContent(id primitive.ObjectID, change func(review *domain.Review) error) (*domain.Review, error) {
+	return store.update(id, func(review *domain.Review) error {
+		if err := change(review); err != nil {
+			return err
+		}
+		review.Version++
+		return nil
+	})
+}
+
+func containsVersion(versions []int64, version int64) bool {
+	for _, candidate := range versions {
+		if candidate == version {
+			return true
+		}
+	}
+	return false
+}
+
+func copyReview(review domain.Review) domain.Review {
+	review.Attachments = append([]domain.Attachment(nil), review.Attachments...)
+	return review
+}
+
+func words(value string) []string {
+	return strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
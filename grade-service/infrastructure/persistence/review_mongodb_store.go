@@ -2,11 +2,14 @@ package persistence
 
 import (
 	"context"
+	"errors"
 	"github.com/mmmajder/zms-devops-grade-service/domain"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"math"
 	"time"
 )
 
@@ -21,24 +24,74 @@ type ReviewMongoDBStore struct {
 
 func NewReviewMongoDBStore(client *mongo.Client) domain.ReviewStore {
 	reviews := client.Database(DATABASE).Collection(COLLECTION)
+	_, err := reviews.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "attachments.id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "sub_reviewed", Value: 1}, {Key: "type", Value: 1}, {Key: "language", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "sub_reviewer", Value: 1}},
+		},
+		{
+			// Reviews carry their own "language" field with codes Mongo can not
+			// stem, so the text index must not use it as a language override.
+			Keys:    bson.D{{Key: "comment", Value: "text"}},
+			Options: options.Index().SetDefaultLanguage("none").SetLanguageOverride("text_language"),
+		},
+	})
+	if err != nil {
+		log.Printf("failed to create review indexes: %v", err)
+	}
 	return &ReviewMongoDBStore{
 		reviews: reviews,
 	}
 }
 
 func (store *ReviewMongoDBStore) Get(id primitive.ObjectID) (*domain.Review, error) {
+	defer observe(COLLECTION, "get")()
 	filter := bson.M{"_id": id}
 	return store.filterOne(filter)
 }
 
-func (store *ReviewMongoDBStore) GetAllBySubReviewed(subReviewed string, reviewType int) ([]*domain.Review, error) {
-	filter := bson.M{"sub_reviewed": subReviewed, "type": reviewType}
+func (store *ReviewMongoDBStore) GetAllBySubReviewed(subReviewed string, reviewType domain.ReviewType) ([]*domain.Review, error) {
+	defer observe(COLLECTION, "get_all_by_sub_reviewed")()
+	filter := bson.M{
+		"sub_reviewed": subReviewed,
+		"type":         reviewType,
+		"status":       bson.M{"$ne": domain.StatusPendingModeration},
+	}
+
+	return store.filter(filter)
+}
+
+func (store *ReviewMongoDBStore) GetMatchingBySubReviewed(subReviewed string, reviewType domain.ReviewType, filter domain.ReviewFilter) ([]*domain.Review, error) {
+	defer observe(COLLECTION, "get_matching_by_sub_reviewed")()
+	conditions := bson.M{
+		"sub_reviewed": subReviewed,
+		"type":         reviewType,
+		"status":       bson.M{"$ne": domain.StatusPendingModeration},
+	}
+	for key, value := range filterConditions(filter) {
+		conditions[key] = value
+	}
+
+	return store.filter(conditions)
+}
 
+func (store *ReviewMongoDBStore) GetAllBySubReviewers(subReviewers []string) ([]*domain.Review, error) {
+	defer observe(COLLECTION, "get_all_by_sub_reviewers")()
+	filter := bson.M{"sub_reviewer": bson.M{"$in": subReviewers}}
 	return store.filter(filter)
 }
 
 func (store *ReviewMongoDBStore) Insert(review *domain.Review) (primitive.ObjectID, error) {
-	review.Id = primitive.NewObjectID()
+	defer observe(COLLECTION, "insert")()
+	if review.Id.IsZero() {
+		review.Id = primitive.NewObjectID()
+	}
+	review.Version = 1
 	result, err := store.reviews.InsertOne(context.TODO(), review)
 	if err != nil {
 		return primitive.NilObjectID, err
@@ -48,51 +101,268 @@ func (store *ReviewMongoDBStore) Insert(review *domain.Review) (primitive.Object
 }
 
 func (store *ReviewMongoDBStore) Delete(id primitive.ObjectID) error {
+	defer observe(COLLECTION, "delete")()
 	filter := bson.M{"_id": id}
-	_, err := store.reviews.DeleteOne(context.TODO(), filter)
+	result, err := store.reviews.DeleteOne(context.TODO(), filter)
 	if err != nil {
 		return err
 	}
+	if result.DeletedCount == 0 {
+		return domain.ErrReviewNotFound
+	}
 	return nil
 }
 
 func (store *ReviewMongoDBStore) DeleteAll() {
+	defer observe(COLLECTION, "delete_all")()
 	store.reviews.DeleteMany(context.TODO(), bson.D{{}})
 }
 
-func (store *ReviewMongoDBStore) Update(id primitive.ObjectID, comment string, grade float32) (*domain.Review, error) {
+func (store *ReviewMongoDBStore) Update(id primitive.ObjectID, comment string, grade float32, language string, sentiment domain.Sentiment, versions []int64) (*domain.Review, error) {
+	defer observe(COLLECTION, "update")()
 	filter := bson.M{"_id": id}
-	update := bson.D{
-		{"$set", bson.D{
-			{"comment", comment},
-			{"grade", grade},
-			{"date_of_modification", time.Now()},
-		}},
+	if versions != nil {
+		filter["version"] = bson.M{"$in": versionConditions(versions)}
 	}
-	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
-	var updatedReview domain.Review
-	err := store.reviews.FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(&updatedReview)
+	update := bson.M{
+		"$set": bson.M{
+			"comment":              comment,
+			"grade":                grade,
+			"language":             language,
+			"sentiment":            sentiment,
+			"date_of_modification": time.Now(),
+		},
+	}
+	review, err := store.updateContent(filter, update)
+	if versions != nil && errors.Is(err, domain.ErrReviewNotFound) {
+		if _, getErr := store.Get(id); getErr != nil {
+			return nil, getErr
+		}
+		return nil, domain.ErrVersionMismatch
+	}
+	return review, err
+}
+
+func (store *ReviewMongoDBStore) SetVoteCounts(id primitive.ObjectID, helpful int, notHelpful int) (*domain.Review, error) {
+	defer observe(COLLECTION, "set_vote_counts")()
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$set": bson.M{
+			"helpful_count":     helpful,
+			"not_helpful_count": notHelpful,
+		},
+	}
+	return store.findOneAndUpdate(filter, update)
+}
+
+func (store *ReviewMongoDBStore) UpdateStatus(id primitive.ObjectID, status domain.ReviewStatus) (*domain.Review, error) {
+	defer observe(COLLECTION, "update_status")()
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$set": bson.M{
+			"status": status,
+		},
+	}
+	return store.findOneAndUpdate(filter, update)
+}
+
+func (store *ReviewMongoDBStore) Flag(id primitive.ObjectID) (*domain.Review, error) {
+	defer observe(COLLECTION, "flag")()
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$set": bson.M{
+			"flagged": true,
+		},
+	}
+	return store.findOneAndUpdate(filter, update)
+}
+
+func (store *ReviewMongoDBStore) AddAttachment(id primitive.ObjectID, attachment domain.Attachment, maxCount int) (*domain.Review, error) {
+	defer observe(COLLECTION, "add_attachment")()
+	filter := bson.M{
+		"_id": id,
+		"$expr": bson.M{
+			"$lt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$attachments", bson.A{}}}}, maxCount},
+		},
+	}
+	update := bson.M{
+		"$push": bson.M{
+			"attachments": attachment,
+		},
+	}
+	review, err := store.updateContent(filter, update)
+	if errors.Is(err, domain.ErrReviewNotFound) {
+		if _, getErr := store.Get(id); getErr != nil {
+			return nil, getErr
+		}
+		return nil, domain.ErrTooManyAttachments
+	}
+	return review, err
+}
+
+func (store *ReviewMongoDBStore) GetByAttachment(attachmentId string) (*domain.Review, error) {
+	defer observe(COLLECTION, "get_by_attachment")()
+	filter := bson.M{"attachments.id": attachmentId}
+	review, err := store.filterOne(filter)
+	if errors.Is(err, domain.ErrReviewNotFound) {
+		return nil, domain.ErrAttachmentNotFound
+	}
+	return review, err
+}
+
+func (store *ReviewMongoDBStore) Search(subReviewed string, reviewType domain.ReviewType, query string, filter domain.ReviewFilter, limit int) ([]*domain.ReviewSearchResult, int, error) {
+	defer observe(COLLECTION, "search")()
+	conditions := bson.M{
+		"$text":        bson.M{"$search": query},
+		"sub_reviewed": subReviewed,
+		"type":         reviewType,
+		"status":       bson.M{"$ne": domain.StatusPendingModeration},
+	}
+	for key, value := range filterConditions(filter) {
+		conditions[key] = value
+	}
+
+	total, err := store.reviews.CountDocuments(context.TODO(), conditions)
 	if err != nil {
+		return nil, 0, err
+	}
+
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}}).
+		SetLimit(int64(limit))
+	cursor, err := store.reviews.Find(context.TODO(), conditions, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var hits []struct {
+		domain.Review `bson:",inline"`
+		Score         float64 `bson:"score"`
+	}
+	if err := cursor.All(context.TODO(), &hits); err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]*domain.ReviewSearchResult, 0, len(hits))
+	for i := range hits {
+		results = append(results, &domain.ReviewSearchResult{Review: &hits[i].Review, Score: hits[i].Score})
+	}
+	return results, int(total), nil
+}
+
+func (store *ReviewMongoDBStore) GetSubjects() ([]domain.ReviewSubject, error) {
+	defer observe(COLLECTION, "get_subjects")()
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"sub_reviewed": "$sub_reviewed", "type": "$type"}}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$_id"}}},
+	}
+	cursor, err := store.reviews.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var subjects []domain.ReviewSubject
+	if err := cursor.All(context.TODO(), &subjects); err != nil {
 		return nil, err
 	}
+	return subjects, nil
+}
 
-	return &updatedReview, nil
+func filterConditions(filter domain.ReviewFilter) bson.M {
+	conditions := bson.M{}
+	if len(filter.Stars) > 0 {
+		ranges := make(bson.A, 0, len(filter.Stars))
+		for _, stars := range filter.Stars {
+			minGrade, maxGrade := domain.StarGradeRange(stars)
+			lower := "$gt"
+			if stars == 1 {
+				lower = "$gte"
+			}
+			if stars == 5 {
+				maxGrade = float32(math.MaxFloat32)
+			}
+			ranges = append(ranges, bson.M{"grade": bson.M{lower: minGrade, "$lte": maxGrade}})
+		}
+		conditions["$or"] = ranges
+	}
+	dateRange := bson.M{}
+	if filter.From != nil {
+		dateRange["$gte"] = *filter.From
+	}
+	if filter.To != nil {
+		dateRange["$lte"] = *filter.To
+	}
+	if len(dateRange) > 0 {
+		conditions["date_of_modification"] = dateRange
+	}
+	if filter.Language != "" {
+		conditions["language"] = filter.Language
+	}
+	return conditions
 }
 
 func (store *ReviewMongoDBStore) filter(filter interface{}) ([]*domain.Review, error) {
 	cursor, err := store.reviews.Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
 	defer cursor.Close(context.TODO())
 
+	return decode(cursor)
+}
+
+func (store *ReviewMongoDBStore) filterOne(filter interface{}) (*domain.Review, error) {
+	var review domain.Review
+	err := store.reviews.FindOne(context.TODO(), filter).Decode(&review)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrReviewNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
-	return decode(cursor)
+	return &review, nil
 }
 
-func (store *ReviewMongoDBStore) filterOne(filter interface{}) (review *domain.Review, err error) {
-	result := store.reviews.FindOne(context.TODO(), filter)
-	err = result.Decode(&review)
-	return
+// updateContent applies an update of the review content and increments the
+// review version in the same operation.
+func (store *ReviewMongoDBStore) updateContent(filter interface{}, update bson.M) (*domain.Review, error) {
+	increments, ok := update["$inc"].(bson.M)
+	if !ok {
+		increments = bson.M{}
+		update["$inc"] = increments
+	}
+	increments["version"] = 1
+	return store.findOneAndUpdate(filter, update)
+}
+
+func (store *ReviewMongoDBStore) findOneAndUpdate(filter interface{}, update bson.M) (*domain.Review, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var review domain.Review
+	err := store.reviews.FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(&review)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// versionConditions lets version 0 match reviews stored before the field
+// existed.
+func versionConditions(versions []int64) bson.A {
+	conditions := make(bson.A, 0, len(versions)+1)
+	for _, version := range versions {
+		conditions = append(conditions, version)
+		if version == 0 {
+			conditions = append(conditions, nil)
+		}
+	}
+	return conditions
 }
 
 func decode(cursor *mongo.Cursor) (reviews []*domain.Review, err error) {
@@ -0,0 +1,73 @@
+package persistence
+
+import (
+	"context"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+)
+
+const (
+	SUMMARY_COLLECTION = "review_summaries"
+)
+
+type ReviewSummaryMongoDBStore struct {
+	summaries *mongo.Collection
+}
+
+func NewReviewSummaryMongoDBStore(client *mongo.Client) domain.ReviewSummaryStore {
+	summaries := client.Database(DATABASE).Collection(SUMMARY_COLLECTION)
+	_, err := summaries.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "sub_reviewed", Value: 1}, {Key: "type", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("failed to create review summary index: %v", err)
+	}
+	return &ReviewSummaryMongoDBStore{
+		summaries: summaries,
+	}
+}
+
+func (store *ReviewSummaryMongoDBStore) Get(subReviewed string, reviewType domain.ReviewType) (*domain.ReviewSummary, error) {
+	filter := bson.M{"sub_reviewed": subReviewed, "type": reviewType}
+	var summary domain.ReviewSummary
+	if err := store.summaries.FindOne(context.TODO(), filter).Decode(&summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+func (store *ReviewSummaryMongoDBStore) Upsert(summary *domain.ReviewSummary) error {
+	filter := bson.M{"sub_reviewed": summary.SubReviewed, "type": summary.Type}
+	opts := options.Replace().SetUpsert(true)
+	_, err := store.summaries.ReplaceOne(context.TODO(), filter, summary, opts)
+	return err
+}
+
+func (store *ReviewSummaryMongoDBStore) Delete(subReviewed string, reviewType domain.ReviewType) error {
+	filter := bson.M{"sub_reviewed": subReviewed, "type": reviewType}
+	_, err := store.summaries.DeleteOne(context.TODO(), filter)
+	return err
+}
+
+func (store *ReviewSummaryMongoDBStore) GetSubjects() ([]domain.ReviewSubject, error) {
+	opts := options.Find().SetProjection(bson.M{"sub_reviewed": 1, "type": 1})
+	cursor, err := store.summaries.Find(context.TODO(), bson.D{{}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var subjects []domain.ReviewSubject
+	if err := cursor.All(context.TODO(), &subjects); err != nil {
+		return nil, err
+	}
+	return subjects, nil
+}
+
+func (store *ReviewSummaryMongoDBStore) DeleteAll() {
+	store.summaries.DeleteMany(context.TODO(), bson.D{{}})
+}
@@ -0,0 +1,96 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+)
+
+const (
+	VOTE_COLLECTION = "review_votes"
+)
+
+type ReviewVoteMongoDBStore struct {
+	votes *mongo.Collection
+}
+
+func NewReviewVoteMongoDBStore(client *mongo.Client) domain.ReviewVoteStore {
+	votes := client.Database(DATABASE).Collection(VOTE_COLLECTION)
+	_, err := votes.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "review_id", Value: 1}, {Key: "voter", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("failed to create review vote index: %v", err)
+	}
+	return &ReviewVoteMongoDBStore{
+		votes: votes,
+	}
+}
+
+func (store *ReviewVoteMongoDBStore) Upsert(vote *domain.ReviewVote) (*domain.ReviewVote, error) {
+	filter := bson.M{"review_id": vote.ReviewId, "voter": vote.Voter}
+	update := bson.M{
+		"$set": bson.M{
+			"helpful":              vote.Helpful,
+			"date_of_modification": vote.DateOfModification,
+		},
+		"$setOnInsert": bson.M{
+			"_id": primitive.NewObjectID(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+	var previous domain.ReviewVote
+	err := store.votes.FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &previous, nil
+}
+
+func (store *ReviewVoteMongoDBStore) Count(reviewId primitive.ObjectID) (int, int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"review_id": reviewId}}},
+		{{Key: "$group", Value: bson.M{"_id": "$helpful", "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err := store.votes.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var helpful, notHelpful int
+	for cursor.Next(context.TODO()) {
+		var group struct {
+			Helpful bool `bson:"_id"`
+			Count   int  `bson:"count"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return 0, 0, err
+		}
+		if group.Helpful {
+			helpful = group.Count
+		} else {
+			notHelpful = group.Count
+		}
+	}
+	return helpful, notHelpful, cursor.Err()
+}
+
+func (store *ReviewVoteMongoDBStore) DeleteByReview(reviewId primitive.ObjectID) error {
+	_, err := store.votes.DeleteMany(context.TODO(), bson.M{"review_id": reviewId})
+	return err
+}
+
+func (store *ReviewVoteMongoDBStore) DeleteAll() {
+	store.votes.DeleteMany(context.TODO(), bson.D{{}})
+}
@@ -0,0 +1,74 @@
+package persistence
+
+import (
+	"context"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+)
+
+const (
+	SUSPICION_COLLECTION = "suspicions"
+)
+
+type SuspicionMongoDBStore struct {
+	suspicions *mongo.Collection
+}
+
+func NewSuspicionMongoDBStore(client *mongo.Client) domain.SuspicionStore {
+	suspicions := client.Database(DATABASE).Collection(SUSPICION_COLLECTION)
+	_, err := suspicions.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("failed to create suspicion index: %v", err)
+	}
+	return &SuspicionMongoDBStore{
+		suspicions: suspicions,
+	}
+}
+
+func (store *SuspicionMongoDBStore) Upsert(suspicion *domain.Suspicion) error {
+	filter := bson.M{"key": suspicion.Key}
+	update := bson.M{
+		"$set": bson.M{
+			"kind":             suspicion.Kind,
+			"sub_reviewed":     suspicion.SubReviewed,
+			"type":             suspicion.Type,
+			"reviewer":         suspicion.Reviewer,
+			"review_ids":       suspicion.ReviewIds,
+			"score":            suspicion.Score,
+			"details":          suspicion.Details,
+			"last_detected_at": suspicion.LastDetectedAt,
+		},
+		"$setOnInsert": bson.M{
+			"_id":              primitive.NewObjectID(),
+			"date_of_creation": suspicion.DateOfCreation,
+		},
+	}
+	_, err := store.suspicions.UpdateOne(context.TODO(), filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (store *SuspicionMongoDBStore) GetAll() ([]*domain.Suspicion, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "last_detected_at", Value: -1}})
+	cursor, err := store.suspicions.Find(context.TODO(), bson.D{{}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	suspicions := make([]*domain.Suspicion, 0)
+	if err := cursor.All(context.TODO(), &suspicions); err != nil {
+		return nil, err
+	}
+	return suspicions, nil
+}
+
+func (store *SuspicionMongoDBStore) DeleteAll() {
+	store.suspicions.DeleteMany(context.TODO(), bson.D{{}})
+}
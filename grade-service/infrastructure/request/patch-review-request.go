@@ -0,0 +1,77 @@
+package request
+
+import (
+	"encoding/json"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"sort"
+)
+
+// PatchReviewRequest is a JSON Merge Patch (RFC 7396) of a review. Only the
+// members present in the patch are validated and changed. Setting language to
+// null goes back to detecting it from the comment; comment and grade can not
+// be removed.
+type PatchReviewRequest struct {
+	Comment  *string  `json:"comment" validate:"omitnil,min=1"`
+	Grade    *float32 `json:"grade" validate:"omitnil,gt=0,max=5"`
+	Language *string  `json:"language" validate:"omitnil,bcp47_language_tag"`
+	// ResetLanguage is set when the patch removes the language.
+	ResetLanguage bool `json:"-"`
+
+	removed []string
+	unknown []string
+}
+
+func (request *PatchReviewRequest) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	*request = PatchReviewRequest{}
+	for name, value := range members {
+		isNull := string(value) == "null"
+		var err error
+		switch name {
+		case "comment":
+			if isNull {
+				request.removed = append(request.removed, name)
+				continue
+			}
+			err = json.Unmarshal(value, &request.Comment)
+		case "grade":
+			if isNull {
+				request.removed = append(request.removed, name)
+				continue
+			}
+			err = json.Unmarshal(value, &request.Grade)
+		case "language":
+			if isNull {
+				request.ResetLanguage = true
+				continue
+			}
+			err = json.Unmarshal(value, &request.Language)
+		default:
+			request.unknown = append(request.unknown, name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	sort.Strings(request.removed)
+	sort.Strings(request.unknown)
+	return nil
+}
+
+func (request PatchReviewRequest) AreValidRequestData() error {
+	fields := make([]domain.FieldError, 0)
+	for _, name := range request.removed {
+		fields = append(fields, domain.FieldError{Field: name, Code: "required", Message: name + " can not be removed"})
+	}
+	for _, name := range request.unknown {
+		fields = append(fields, domain.FieldError{Field: name, Code: "unknown", Message: name + " can not be changed"})
+	}
+	if len(fields) > 0 {
+		return domain.NewValidationError("invalid_request", "Request data is invalid", fields...)
+	}
+	return validateStruct(request)
+}
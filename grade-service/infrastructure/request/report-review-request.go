@@ -0,0 +1,10 @@
+package request
+
+type ReportReviewRequest struct {
+	Reason string `json:"reason" validate:"required,oneof=spam fake offensive irrelevant other"`
+	Text   string `json:"text" validate:"required_if=Reason other,max=1000"`
+}
+
+func (request ReportReviewRequest) AreValidRequestData() error {
+	return validateStruct(request)
+}
@@ -0,0 +1,44 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// ReviewTypeValue accepts a review type either as a name ("host") or as the
+// numeric value older clients send (0).
+type ReviewTypeValue string
+
+func (value *ReviewTypeValue) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*value = ""
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return err
+		}
+		*value = ReviewTypeValue(name)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("review type must be a name or a number: %w", err)
+	}
+	*value = ReviewTypeValue(number.String())
+	return nil
+}
+
+// String returns the raw review type. An omitted type keeps meaning host, as
+// it did when the field was a plain integer.
+func (value ReviewTypeValue) String() string {
+	if value == "" {
+		return "0"
+	}
+	return string(value)
+}
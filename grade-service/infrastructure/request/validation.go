@@ -0,0 +1,66 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"reflect"
+	"strings"
+)
+
+var validate = newValidator()
+
+// newValidator reports fields by their JSON names, which is what clients
+// send and see.
+func newValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return validate
+}
+
+// validateStruct turns validator failures into a validation error with one
+// entry per invalid field.
+func validateStruct(request interface{}) error {
+	err := validate.Struct(request)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+	fields := make([]domain.FieldError, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		fields = append(fields, domain.FieldError{
+			Field:   fieldError.Field(),
+			Code:    fieldError.Tag(),
+			Message: fieldMessage(fieldError),
+		})
+	}
+	return domain.NewValidationError("invalid_request", "Request data is invalid", fields...)
+}
+
+func fieldMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required", "required_if":
+		return fmt.Sprintf("%s is required", fieldError.Field())
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", fieldError.Field(), fieldError.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", fieldError.Field(), fieldError.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", fieldError.Field(), strings.ReplaceAll(fieldError.Param(), " ", ", "))
+	case "bcp47_language_tag":
+		return fmt.Sprintf("%s must be a BCP 47 language tag", fieldError.Field())
+	default:
+		return fmt.Sprintf("%s is invalid", fieldError.Field())
+	}
+}
@@ -0,0 +1,61 @@
+package translation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"sync"
+)
+
+// CachedTranslator remembers up to capacity translations and evicts the
+// oldest entry once it is full.
+type CachedTranslator struct {
+	translator domain.Translator
+	capacity   int
+	mutex      sync.Mutex
+	entries    map[string]string
+	order      []string
+}
+
+func NewCachedTranslator(translator domain.Translator, capacity int) domain.Translator {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &CachedTranslator{
+		translator: translator,
+		capacity:   capacity,
+		entries:    make(map[string]string, capacity),
+	}
+}
+
+func (translator *CachedTranslator) Translate(text string, sourceLanguage string, targetLanguage string) (string, error) {
+	key := cacheKey(text, sourceLanguage, targetLanguage)
+	translator.mutex.Lock()
+	translated, ok := translator.entries[key]
+	translator.mutex.Unlock()
+	if ok {
+		return translated, nil
+	}
+
+	translated, err := translator.translator.Translate(text, sourceLanguage, targetLanguage)
+	if err != nil {
+		return "", err
+	}
+
+	translator.mutex.Lock()
+	defer translator.mutex.Unlock()
+	if _, exists := translator.entries[key]; !exists {
+		if len(translator.order) >= translator.capacity && len(translator.order) > 0 {
+			delete(translator.entries, translator.order[0])
+			translator.order = translator.order[1:]
+		}
+		translator.order = append(translator.order, key)
+	}
+	translator.entries[key] = translated
+	return translated, nil
+}
+
+func cacheKey(text string, sourceLanguage string, targetLanguage string) string {
+	hash := sha256.Sum256([]byte(sourceLanguage + "\x00" + targetLanguage + "\x00" + text))
+	return hex.EncodeToString(hash[:])
+}
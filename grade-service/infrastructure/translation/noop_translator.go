@@ -0,0 +1,16 @@
+package translation
+
+import "github.com/mmmajder/zms-devops-grade-service/domain"
+
+// NoopTranslator translates nothing and fails with ErrTranslationUnavailable,
+// so reviews are listed without translatedComment until a real translation
+// backend is configured.
+type NoopTranslator struct{}
+
+func NewNoopTranslator() domain.Translator {
+	return &NoopTranslator{}
+}
+
+func (translator *NoopTranslator) Translate(text string, sourceLanguage string, targetLanguage string) (string, error) {
+	return "", domain.ErrTranslationUnavailable
+}
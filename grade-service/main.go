@@ -68,14 +68,20 @@ func main() {
 
 	loki, err := initPromtailClient(config.LokiHost)
 
-	producer, _ := kafka.NewProducer(&kafka.ConfigMap{
-		"bootstrap.servers": config.BootstrapServers,
-		"security.protocol": "sasl_plaintext",
-		"sasl.mechanism":    "PLAIN",
-		"sasl.username":     "user1",
-		"sasl.password":     config.KafkaAuthPassword,
-	})
-	defer producer.Close()
+	var producer *kafka.Producer
+	if config.EventPublisher == "kafka" {
+		producer, err = kafka.NewProducer(&kafka.ConfigMap{
+			"bootstrap.servers": config.BootstrapServers,
+			"security.protocol": "sasl_plaintext",
+			"sasl.mechanism":    "PLAIN",
+			"sasl.username":     "user1",
+			"sasl.password":     config.KafkaAuthPassword,
+		})
+		if err != nil {
+			log.Fatalf("Error creating Kafka producer: %v", err)
+		}
+		defer producer.Close()
+	}
 
 	server := startup.NewServer(config, tp, loki)
 	server.Start(producer)
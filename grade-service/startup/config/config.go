@@ -1,33 +1,129 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+)
 
 type Config struct {
-	Port              string
-	DBUsername        string
-	DBPassword        string
-	DBHost            string
-	DBPort            string
-	BootstrapServers  string
-	KafkaAuthPassword string
-	BookingHost       string
-	BookingPort       string
-	JaegerHost        string
-	LokiHost          string
+	Port                 string
+	DBUsername           string
+	DBPassword           string
+	DBHost               string
+	DBPort               string
+	BootstrapServers     string
+	KafkaAuthPassword    string
+	BookingHost          string
+	BookingPort          string
+	JaegerHost           string
+	LokiHost             string
+	ReportThreshold      int
+	BlobStore            string
+	BlobStorePath        string
+	AttachmentMaxSize    int
+	AttachmentMaxCount   int
+	AttachmentSigningKey string
+	AttachmentUrlTTL     int
+	TranslationCacheSize int
+	AspectSummaryPeriod  int
+	FraudDetectionPeriod int
+	FraudBurstWindow     int
+	FraudBurstThreshold  int
+	FraudExtremeReviews  int
+	FraudDuplicatePct    int
+	FraudFreshDays       int
+	FraudFreshThreshold  int
+	FraudHidePct         int
+	FraudAutoHide        bool
+	RateLimitStore       string
+	RateLimitTrustedHops int
+	RateLimitCreate      int
+	RateLimitUpdate      int
+	RateLimitDelete      int
+	HealthCheckTimeout   int
+	IdempotencyStore     string
+	IdempotencyTTL       int
+	IdempotencyLease     int
+	ApiV1Deprecation     string
+	ApiV1Sunset          string
+	EventPublisher       string
+	EventPublisherPath   string
+	Topics               Topics
+}
+
+type Topics struct {
+	HostRatingChanged          string
+	HostReviewCreated          string
+	AccommodationRatingChanged string
+	AccommodationReviewCreated string
+	GuestRatingChanged         string
 }
 
 func NewConfig() *Config {
 	return &Config{
-		Port:              os.Getenv("SERVICE_PORT"),
-		DBUsername:        os.Getenv("MONGO_INITDB_ROOT_USERNAME"),
-		DBPassword:        os.Getenv("MONGO_INITDB_ROOT_PASSWORD"),
-		DBHost:            os.Getenv("DB_HOST"),
-		DBPort:            os.Getenv("DB_PORT"),
-		BootstrapServers:  os.Getenv("KAFKA_BOOTSTRAP_SERVERS"),
-		KafkaAuthPassword: os.Getenv("KAFKA_AUTH_PASSWORD"),
-		BookingHost:       os.Getenv("BOOKING_HOST"),
-		BookingPort:       os.Getenv("BOOKING_PORT"),
-		JaegerHost:        os.Getenv("JAEGER_ENDPOINT"),
-		LokiHost:          os.Getenv("LOKI_ENDPOINT"),
+		Port:                 os.Getenv("SERVICE_PORT"),
+		DBUsername:           os.Getenv("MONGO_INITDB_ROOT_USERNAME"),
+		DBPassword:           os.Getenv("MONGO_INITDB_ROOT_PASSWORD"),
+		DBHost:               os.Getenv("DB_HOST"),
+		DBPort:               os.Getenv("DB_PORT"),
+		BootstrapServers:     os.Getenv("KAFKA_BOOTSTRAP_SERVERS"),
+		KafkaAuthPassword:    os.Getenv("KAFKA_AUTH_PASSWORD"),
+		BookingHost:          os.Getenv("BOOKING_HOST"),
+		BookingPort:          os.Getenv("BOOKING_PORT"),
+		JaegerHost:           os.Getenv("JAEGER_ENDPOINT"),
+		LokiHost:             os.Getenv("LOKI_ENDPOINT"),
+		ReportThreshold:      getIntEnv("REVIEW_REPORT_THRESHOLD", 3),
+		BlobStore:            getEnv("BLOB_STORE", "gridfs"),
+		BlobStorePath:        getEnv("BLOB_STORE_PATH", "/tmp/grade-attachments"),
+		AttachmentMaxSize:    getIntEnv("ATTACHMENT_MAX_SIZE", 5<<20),
+		AttachmentMaxCount:   getIntEnv("ATTACHMENT_MAX_COUNT", 5),
+		AttachmentSigningKey: os.Getenv("ATTACHMENT_SIGNING_KEY"),
+		AttachmentUrlTTL:     getIntEnv("ATTACHMENT_URL_TTL_MINUTES", 60),
+		TranslationCacheSize: getIntEnv("TRANSLATION_CACHE_SIZE", 1000),
+		AspectSummaryPeriod:  getIntEnv("ASPECT_SUMMARY_PERIOD_MINUTES", 15),
+		FraudDetectionPeriod: getIntEnv("FRAUD_DETECTION_PERIOD_MINUTES", 60),
+		FraudBurstWindow:     getIntEnv("FRAUD_BURST_WINDOW_MINUTES", 60),
+		FraudBurstThreshold:  getIntEnv("FRAUD_BURST_THRESHOLD", 5),
+		FraudExtremeReviews:  getIntEnv("FRAUD_EXTREME_MIN_REVIEWS", 3),
+		FraudDuplicatePct:    getIntEnv("FRAUD_DUPLICATE_SIMILARITY_PERCENT", 80),
+		FraudFreshDays:       getIntEnv("FRAUD_FRESH_ACCOUNT_DAYS", 7),
+		FraudFreshThreshold:  getIntEnv("FRAUD_FRESH_ACCOUNT_THRESHOLD", 3),
+		FraudHidePct:         getIntEnv("FRAUD_HIDE_SCORE_PERCENT", 90),
+		FraudAutoHide:        getEnv("FRAUD_AUTO_HIDE", "false") == "true",
+		RateLimitStore:       getEnv("RATE_LIMIT_STORE", "memory"),
+		RateLimitTrustedHops: getIntEnv("RATE_LIMIT_TRUSTED_HOPS", 1),
+		RateLimitCreate:      getIntEnv("RATE_LIMIT_CREATE_PER_MINUTE", 10),
+		RateLimitUpdate:      getIntEnv("RATE_LIMIT_UPDATE_PER_MINUTE", 30),
+		RateLimitDelete:      getIntEnv("RATE_LIMIT_DELETE_PER_MINUTE", 30),
+		HealthCheckTimeout:   getIntEnv("HEALTH_CHECK_TIMEOUT_MS", 2000),
+		IdempotencyStore:     getEnv("IDEMPOTENCY_STORE", "memory"),
+		IdempotencyTTL:       getIntEnv("IDEMPOTENCY_TTL_HOURS", 24),
+		IdempotencyLease:     getIntEnv("IDEMPOTENCY_LEASE_SECONDS", 60),
+		ApiV1Deprecation:     os.Getenv("API_V1_DEPRECATION_DATE"),
+		ApiV1Sunset:          os.Getenv("API_V1_SUNSET_DATE"),
+		EventPublisher:       getEnv("EVENT_PUBLISHER", "kafka"),
+		EventPublisherPath:   getEnv("EVENT_PUBLISHER_PATH", "/tmp/grade-events.ndjson"),
+		Topics: Topics{
+			HostRatingChanged:          getEnv("HOST_RATING_CHANGED_TOPIC", "host-rating.changed"),
+			HostReviewCreated:          getEnv("HOST_REVIEW_CREATED_TOPIC", "host-review.created"),
+			AccommodationRatingChanged: getEnv("ACCOMMODATION_RATING_CHANGED_TOPIC", "accommodation-rating.changed"),
+			AccommodationReviewCreated: getEnv("ACCOMMODATION_REVIEW_CREATED_TOPIC", "accommodation-review.created"),
+			GuestRatingChanged:         getEnv("GUEST_RATING_CHANGED_TOPIC", "guest-rating.changed"),
+		},
+	}
+}
+
+func getEnv(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
 	}
+	return value
 }
@@ -13,6 +13,7 @@ var reviews = []*domain.Review{
 		SubReviewed:        "57325353-5469-4930-8ec9-35c003e1b967",
 		ReviewerFullName:   "Zorica Vukovic",
 		DateOfModification: time.Now(),
+		Language:           "en",
 	},
 	{
 		Comment:            "At least everything was new. Apartment was clean. Excellent accommodation!",
@@ -21,6 +22,7 @@ var reviews = []*domain.Review{
 		SubReviewed:        "57325353-5469-4930-8ec9-35c003e1b967",
 		ReviewerFullName:   "Saska Topalovic",
 		DateOfModification: time.Now(),
+		Language:           "en",
 	},
 	{
 		Comment:            "Luxury Villa 2",
@@ -29,5 +31,6 @@ var reviews = []*domain.Review{
 		SubReviewed:        "88895353-5469-4930-8ec9-35c003e1b967",
 		ReviewerFullName:   "Saska Topalovic",
 		DateOfModification: time.Now(),
+		Language:           "en",
 	},
 }
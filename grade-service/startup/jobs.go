@@ -0,0 +1,65 @@
+package startup
+
+import (
+	"context"
+	"github.com/mmmajder/zms-devops-grade-service/application"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/util"
+	"log"
+	"time"
+)
+
+// scheduleAspectSummaries rebuilds the aspect summaries right away and then
+// periodically, so summaries lag behind new reviews by at most one period.
+func (server *Server) scheduleAspectSummaries(aspectService *application.AspectService) {
+	period := jobPeriod("ASPECT_SUMMARY_PERIOD_MINUTES", server.config.AspectSummaryPeriod)
+	go func() {
+		server.summarizeAspects(aspectService)
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for range ticker.C {
+			server.summarizeAspects(aspectService)
+		}
+	}()
+}
+
+func (server *Server) summarizeAspects(aspectService *application.AspectService) {
+	_, span := server.traceProvider.Tracer(domain.ServiceName).Start(context.Background(), "summarize-aspects")
+	defer func() { span.End() }()
+	if err := aspectService.SummarizeAll(span, server.loki); err != nil {
+		util.HttpTraceError(err, "failed to summarize aspects", span, server.loki, "summarizeAspects", "")
+	}
+}
+
+// scheduleFraudDetection rescans every subject periodically, catching
+// patterns that only emerge over time such as reviewers turning extreme.
+func (server *Server) scheduleFraudDetection(moderationService *application.ModerationService) {
+	period := jobPeriod("FRAUD_DETECTION_PERIOD_MINUTES", server.config.FraudDetectionPeriod)
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for range ticker.C {
+			server.detectFraud(moderationService)
+		}
+	}()
+}
+
+func (server *Server) detectFraud(moderationService *application.ModerationService) {
+	_, span := server.traceProvider.Tracer(domain.ServiceName).Start(context.Background(), "detect-fraud")
+	defer func() { span.End() }()
+	if err := moderationService.InspectAll(span, server.loki); err != nil {
+		util.HttpTraceError(err, "failed to inspect reviews", span, server.loki, "detectFraud", "")
+	}
+	if err := moderationService.HideReported(span, server.loki); err != nil {
+		util.HttpTraceError(err, "failed to hide reported reviews", span, server.loki, "detectFraud", "")
+	}
+}
+
+// jobPeriod refuses to start the service with a period time.NewTicker would
+// panic on.
+func jobPeriod(key string, minutes int) time.Duration {
+	if minutes <= 0 {
+		log.Fatalf("invalid %s: period must be a positive number of minutes, got %d", key, minutes)
+	}
+	return time.Duration(minutes) * time.Minute
+}
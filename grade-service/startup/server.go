@@ -1,8 +1,8 @@
 package startup
 
 import (
+	"context"
 	"fmt"
-	booking "github.com/ZMS-DevOps/booking-service/proto"
 	"github.com/afiskon/promtail-client/promtail"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/gorilla/mux"
@@ -10,12 +10,17 @@ import (
 	"github.com/mmmajder/zms-devops-grade-service/application/external"
 	"github.com/mmmajder/zms-devops-grade-service/domain"
 	"github.com/mmmajder/zms-devops-grade-service/infrastructure/api"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/messaging"
 	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/translation"
 	"github.com/mmmajder/zms-devops-grade-service/startup/config"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"log"
 	"net/http"
+	"time"
 )
 
 type Server struct {
@@ -42,23 +47,209 @@ func (server *Server) Start(producer *kafka.Producer) {
 func (server *Server) setupHandlers(producer *kafka.Producer) {
 	mongoClient := server.initMongoClient()
 	reviewStore := server.initReviewStore(mongoClient)
+	reviewVoteStore := server.initReviewVoteStore(mongoClient)
 	bookingClient := external.NewBookingClient(server.getBookingAddress())
 
-	reviewService := server.initReviewService(reviewStore, producer, bookingClient)
+	attachmentService := server.initAttachmentService(reviewStore, server.initBlobStore(mongoClient))
+	attachmentHandler := server.initAttachmentHandler(attachmentService)
+
+	aspectService := server.initAspectService(reviewStore, server.initReviewSummaryStore(mongoClient))
+	server.scheduleAspectSummaries(aspectService)
+
+	fraudDetector := server.initFraudDetector(reviewStore, server.initSuspicionStore(mongoClient))
+
+	reviewService := server.initReviewService(reviewStore, reviewVoteStore, server.initRatingVersionStore(mongoClient), server.initEventPublisher(producer), bookingClient, attachmentService, aspectService, fraudDetector)
 	reviewHandler := server.initReviewHandler(reviewService)
+	moderationService := server.initModerationService(reviewService, reviewStore, server.initReviewAbuseReportStore(mongoClient))
+	moderationHandler := server.initModerationHandler(moderationService)
+	server.scheduleFraudDetection(moderationService)
 
-	reviewHandler.Init(server.router)
+	server.router.Use(api.TracingMiddleware(server.traceProvider))
+	server.router.Use(api.MetricsMiddleware)
+	api.InstrumentUnmatched(server.router)
+	server.router.Use(server.initRateLimiter(mongoClient).Middleware)
+	server.router.Use(server.initRequestValidator().Middleware)
+	server.router.Use(server.initIdempotency(mongoClient).Middleware)
+	server.router.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
+
+	healthHandler := api.NewHealthHandler(server.initHealthService(mongoClient, producer, bookingClient))
+
+	healthHandler.Init(server.router)
+	api.MountVersions(server.router, server.initApiV1Deprecation(), api.NewOpenAPIHandler(), attachmentHandler, moderationHandler, reviewHandler)
 }
 
-func (server *Server) initReviewService(store domain.ReviewStore, producer *kafka.Producer, bookingClient booking.BookingServiceClient) *application.ReviewService {
+// initApiV1Deprecation reads the dates announced on v1 responses. Either may
+// be left empty.
+func (server *Server) initApiV1Deprecation() api.Deprecation {
+	return api.Deprecation{
+		Date:      parseDate("API_V1_DEPRECATION_DATE", server.config.ApiV1Deprecation),
+		Sunset:    parseDate("API_V1_SUNSET_DATE", server.config.ApiV1Sunset),
+		Successor: domain.GradeV2ContextPath,
+	}
+}
+
+func (server *Server) initReviewService(store domain.ReviewStore, voteStore domain.ReviewVoteStore, versionStore domain.RatingVersionStore, publisher domain.EventPublisher, bookingClient external.BookingServiceClient, attachmentService *application.AttachmentService, aspectService *application.AspectService, fraudDetector *application.FraudDetector) *application.ReviewService {
 
-	return application.NewReviewService(store, &http.Client{}, producer, bookingClient, server.loki)
+	return application.NewReviewService(store, voteStore, versionStore, &http.Client{}, publisher, bookingClient, server.initReviewTypeRegistry(), attachmentService, aspectService, fraudDetector, server.initTranslator(), server.loki)
+}
+
+func (server *Server) initReviewTypeRegistry() *application.ReviewTypeRegistry {
+	topics := server.config.Topics
+	registry, err := application.DefaultReviewTypeRegistry(application.ReviewTopics{
+		HostRatingChanged:          topics.HostRatingChanged,
+		HostReviewCreated:          topics.HostReviewCreated,
+		AccommodationRatingChanged: topics.AccommodationRatingChanged,
+		AccommodationReviewCreated: topics.AccommodationReviewCreated,
+		GuestRatingChanged:         topics.GuestRatingChanged,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return registry
+}
+
+// initEventPublisher picks the backend named by EVENT_PUBLISHER. Only the
+// kafka backend needs the producer.
+func (server *Server) initEventPublisher(producer *kafka.Producer) domain.EventPublisher {
+	switch server.config.EventPublisher {
+	case "log":
+		return messaging.NewLogPublisher()
+	case "file":
+		publisher, err := messaging.NewFilePublisher(server.config.EventPublisherPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return publisher
+	default:
+		if producer == nil {
+			log.Fatal("kafka producer is not initialized")
+		}
+		return messaging.NewKafkaPublisher(producer)
+	}
 }
 
 func (server *Server) initReviewHandler(authService *application.ReviewService) *api.ReviewHandler {
 	return api.NewReviewHandler(authService, server.traceProvider, server.loki)
 }
 
+func (server *Server) initAttachmentService(store domain.ReviewStore, blobStore domain.BlobStore) *application.AttachmentService {
+	limits := application.AttachmentLimits{
+		MaxSize:             int64(server.config.AttachmentMaxSize),
+		MaxCount:            server.config.AttachmentMaxCount,
+		AllowedContentTypes: []string{"image/jpeg", "image/png", "image/webp"},
+	}
+	return application.NewAttachmentService(store, blobStore, limits, server.getAttachmentSigningKey(), time.Duration(server.config.AttachmentUrlTTL)*time.Minute)
+}
+
+func (server *Server) initAttachmentHandler(attachmentService *application.AttachmentService) *api.AttachmentHandler {
+	return api.NewAttachmentHandler(attachmentService, server.traceProvider, server.loki)
+}
+
+func (server *Server) initModerationService(reviewService *application.ReviewService, store domain.ReviewStore, reportStore domain.ReviewAbuseReportStore) *application.ModerationService {
+	return application.NewModerationService(reviewService, store, reportStore, server.config.ReportThreshold)
+}
+
+func (server *Server) initModerationHandler(moderationService *application.ModerationService) *api.ModerationHandler {
+	return api.NewModerationHandler(moderationService, server.traceProvider, server.loki)
+}
+
+func (server *Server) initAspectService(store domain.ReviewStore, summaryStore domain.ReviewSummaryStore) *application.AspectService {
+	return application.NewAspectService(store, summaryStore)
+}
+
+func (server *Server) initFraudDetector(store domain.ReviewStore, suspicionStore domain.SuspicionStore) *application.FraudDetector {
+	settings := application.FraudDetectionSettings{
+		BurstWindow:           time.Duration(server.config.FraudBurstWindow) * time.Minute,
+		BurstThreshold:        server.config.FraudBurstThreshold,
+		ExtremeMinReviews:     server.config.FraudExtremeReviews,
+		DuplicateSimilarity:   float64(server.config.FraudDuplicatePct) / 100,
+		FreshAccountAge:       time.Duration(server.config.FraudFreshDays) * 24 * time.Hour,
+		FreshAccountThreshold: server.config.FraudFreshThreshold,
+		HideScore:             float64(server.config.FraudHidePct) / 100,
+		AutoHide:              server.config.FraudAutoHide,
+	}
+	return application.NewFraudDetector(store, suspicionStore, settings)
+}
+
+func (server *Server) initRateLimiter(client *mongo.Client) *api.RateLimiter {
+	var store domain.RateLimitStore
+	if server.config.RateLimitStore == "mongo" {
+		store = persistence.NewRateLimitMongoDBStore(client)
+	} else {
+		store = persistence.NewRateLimitMemoryStore()
+	}
+	return api.NewRateLimiter(store, server.config.RateLimitTrustedHops).
+		Limit(http.MethodPost, domain.GradeContextPath, perMinute(server.config.RateLimitCreate)).
+		Limit(http.MethodPut, domain.GradeContextPath+"/{id}", perMinute(server.config.RateLimitUpdate)).
+		Alias(http.MethodPatch, domain.GradeContextPath+"/{id}", http.MethodPut, domain.GradeContextPath+"/{id}").
+		Limit(http.MethodDelete, domain.GradeContextPath+"/{id}", perMinute(server.config.RateLimitDelete)).
+		Alias(http.MethodDelete, domain.GradeContextPath+"/{id}/{type}", http.MethodDelete, domain.GradeContextPath+"/{id}")
+}
+
+func (server *Server) initRequestValidator() *api.RequestValidator {
+	validator, err := api.NewRequestValidator()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return validator
+}
+
+func (server *Server) initIdempotency(client *mongo.Client) *api.Idempotency {
+	var store domain.IdempotencyStore
+	if server.config.IdempotencyStore == "mongo" {
+		store = persistence.NewIdempotencyMongoDBStore(client)
+	} else {
+		store = persistence.NewIdempotencyMemoryStore()
+	}
+	return api.NewIdempotency(store, time.Duration(server.config.IdempotencyTTL)*time.Hour, time.Duration(server.config.IdempotencyLease)*time.Second).
+		Protect(http.MethodPost, domain.GradeContextPath).
+		Protect(http.MethodPut, domain.GradeContextPath+"/{id}").
+		Protect(http.MethodPatch, domain.GradeContextPath+"/{id}").
+		Protect(http.MethodDelete, domain.GradeContextPath+"/{id}").
+		Protect(http.MethodDelete, domain.GradeContextPath+"/{id}/{type}").
+		Protect(http.MethodPost, domain.GradeContextPath+"/{id}/helpful").
+		Protect(http.MethodPost, domain.GradeContextPath+"/{id}/not-helpful").
+		Protect(http.MethodPost, domain.GradeContextPath+"/{id}/report")
+}
+
+func parseDate(key string, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return date
+}
+
+func perMinute(requests int) domain.RateLimit {
+	return domain.RateLimit{Requests: requests, Period: time.Minute}
+}
+
+func (server *Server) initHealthService(client *mongo.Client, producer *kafka.Producer, bookingClient *external.BookingClient) *application.HealthService {
+	healthService := application.NewHealthService(time.Duration(server.config.HealthCheckTimeout)*time.Millisecond).
+		Register("mongo", func(ctx context.Context) error {
+			return client.Ping(ctx, readpref.Primary())
+		}).
+		Register("booking", bookingClient.CheckConnectivity)
+	if producer == nil {
+		return healthService
+	}
+	return healthService.Register("kafka", func(ctx context.Context) error {
+		timeout := time.Duration(server.config.HealthCheckTimeout) * time.Millisecond
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = time.Until(deadline)
+		}
+		_, err := producer.GetMetadata(nil, false, int(timeout.Milliseconds()))
+		return err
+	})
+}
+
+func (server *Server) initTranslator() domain.Translator {
+	return translation.NewCachedTranslator(translation.NewNoopTranslator(), server.config.TranslationCacheSize)
+}
+
 func (server *Server) initMongoClient() *mongo.Client {
 	client, err := persistence.GetClient(server.config.DBUsername, server.config.DBPassword, server.config.DBHost, server.config.DBPort)
 	if err != nil {
@@ -76,6 +267,60 @@ func (server *Server) initReviewStore(client *mongo.Client) domain.ReviewStore {
 	return store
 }
 
+func (server *Server) initReviewVoteStore(client *mongo.Client) domain.ReviewVoteStore {
+	store := persistence.NewReviewVoteMongoDBStore(client)
+	store.DeleteAll()
+	return store
+}
+
+// initRatingVersionStore keeps the stored versions: consumers discard
+// ratings below the highest version they have seen, so versions must not
+// start over when the service restarts.
+func (server *Server) initRatingVersionStore(client *mongo.Client) domain.RatingVersionStore {
+	return persistence.NewRatingVersionMongoDBStore(client)
+}
+
+func (server *Server) initReviewAbuseReportStore(client *mongo.Client) domain.ReviewAbuseReportStore {
+	store := persistence.NewReviewAbuseReportMongoDBStore(client)
+	store.DeleteAll()
+	return store
+}
+
+func (server *Server) initReviewSummaryStore(client *mongo.Client) domain.ReviewSummaryStore {
+	store := persistence.NewReviewSummaryMongoDBStore(client)
+	store.DeleteAll()
+	return store
+}
+
+func (server *Server) initSuspicionStore(client *mongo.Client) domain.SuspicionStore {
+	store := persistence.NewSuspicionMongoDBStore(client)
+	store.DeleteAll()
+	return store
+}
+
+func (server *Server) initBlobStore(client *mongo.Client) domain.BlobStore {
+	var store domain.BlobStore
+	var err error
+	if server.config.BlobStore == "local" {
+		store, err = persistence.NewLocalBlobStore(server.config.BlobStorePath)
+	} else {
+		store, err = persistence.NewGridFSBlobStore(client)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return store
+}
+
+// getAttachmentSigningKey requires a configured key, since every replica has
+// to verify the URLs the others signed, also after a restart.
+func (server *Server) getAttachmentSigningKey() []byte {
+	if server.config.AttachmentSigningKey == "" {
+		log.Fatal("ATTACHMENT_SIGNING_KEY is not set")
+	}
+	return []byte(server.config.AttachmentSigningKey)
+}
+
 func (server *Server) getBookingAddress() string {
 	return fmt.Sprintf("%s:%s", server.config.BookingHost, server.config.BookingPort)
 }
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"github.com/mmmajder/zms-devops-grade-service/application"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"testing"
+)
+
+func insertComments(t *testing.T, store domain.ReviewStore, comments map[string]float32) {
+	t.Helper()
+	for comment, grade := range comments {
+		id := insertReviews(t, store, "host-1", domain.Host, grade)[0]
+		if _, err := store.Update(id, comment, grade, "en", domain.Sentiment{}, nil); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+	}
+}
+
+func containsTerm(aspects []dto.AspectDTO, term string) bool {
+	for _, aspect := range aspects {
+		if aspect.Term == term {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSummarizeAspects(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	service := application.NewAspectService(store, newSummaryStore())
+	insertComments(t, store, map[string]float32{
+		"Great location, noisy street":       4.5,
+		"Perfect location but a noisy night": 5,
+		"Noisy neighbours all night":         1,
+		"Noisy and dirty bathroom":           2,
+		"Dirty bathroom, tiny room":          1,
+	})
+
+	if err := service.SummarizeAll(span, nopLoki{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	positive, negative, err := service.GetAspects("host-1", domain.Host)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(positive) == 0 || positive[0].Term != "location" {
+		t.Errorf("top positive = %v, want location first", positive)
+	}
+	if !containsTerm(negative, "dirty bathroom") || containsTerm(negative, "location") {
+		t.Errorf("top negative = %v, want dirty bathroom but not location", negative)
+	}
+}
+
+func TestSummaryRemovedWithReviews(t *testing.T) {
+	tests := []struct {
+		name   string
+		remove func(domain.ReviewStore, domain.Review) error
+	}{
+		{"deleted", func(store domain.ReviewStore, review domain.Review) error {
+			return store.Delete(review.Id)
+		}},
+		{"hidden", func(store domain.ReviewStore, review domain.Review) error {
+			_, err := store.UpdateStatus(review.Id, domain.StatusPendingModeration)
+			return err
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			service := application.NewAspectService(store, newSummaryStore())
+			insertComments(t, store, map[string]float32{"Quiet garden": 5, "Lovely quiet garden": 4})
+			if err := service.SummarizeAll(span, nopLoki{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			reviews, _ := store.GetAllBySubReviewed("host-1", domain.Host)
+			for _, review := range reviews {
+				if err := test.remove(store, *review); err != nil {
+					t.Fatalf("remove: %v", err)
+				}
+			}
+			if err := service.SummarizeAll(span, nopLoki{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			positive, negative, err := service.GetAspects("host-1", domain.Host)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(positive) != 0 || len(negative) != 0 {
+				t.Errorf("aspects = %v and %v, want none once the reviews are %s", positive, negative, test.name)
+			}
+		})
+	}
+}
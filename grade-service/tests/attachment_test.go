@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/application"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/api"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var pngImage = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 64)...)
+
+func newAttachmentRouter(t *testing.T, store domain.ReviewStore, urlTTL time.Duration) *mux.Router {
+	t.Helper()
+	blobStore, err := persistence.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("blob store: %v", err)
+	}
+	limits := application.AttachmentLimits{MaxSize: 1 << 10, MaxCount: 1, AllowedContentTypes: []string{"image/png"}}
+	attachments := application.NewAttachmentService(store, blobStore, limits, []byte("test-key"), urlTTL)
+	traceProvider := sdktrace.NewTracerProvider()
+
+	router := mux.NewRouter()
+	api.MountVersions(router, api.Deprecation{},
+		api.NewAttachmentHandler(attachments, traceProvider, nopLoki{}),
+		api.NewReviewHandler(newReviewService(store, &recordingPublisher{}, &fakeBookingClient{}), traceProvider, nopLoki{}))
+	return router
+}
+
+func uploadAttachment(router http.Handler, id string, content []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", "room.png")
+	part.Write(content)
+	writer.Close()
+
+	r := httptest.NewRequest(http.MethodPost, domain.GradeContextPath+"/"+id+"/attachments", &body)
+	r.Header.Set(domain.ContentType, writer.FormDataContentType())
+	r.Header.Set(domain.JwtPayloadHeader, jwtPayload("reviewer", domain.GuestRole))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestAttachmentUpload(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  []byte
+		previous int
+		status   int
+		wantCode string
+	}{
+		{name: "image", content: pngImage, status: http.StatusCreated},
+		{name: "too large", content: append(pngImage, make([]byte, 2<<10)...), status: http.StatusRequestEntityTooLarge, wantCode: domain.ErrAttachmentTooLarge.Code},
+		{name: "not an image", content: []byte("just some text"), status: http.StatusUnsupportedMediaType, wantCode: domain.ErrUnsupportedAttachmentType.Code},
+		{name: "over the count", content: pngImage, previous: 1, status: http.StatusConflict, wantCode: domain.ErrTooManyAttachments.Code},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			id := insertReview(t, store)
+			router := newAttachmentRouter(t, store, time.Minute)
+			for i := 0; i < test.previous; i++ {
+				if w := uploadAttachment(router, id, pngImage); w.Code != http.StatusCreated {
+					t.Fatalf("previous upload: status = %d: %s", w.Code, w.Body.String())
+				}
+			}
+
+			w := uploadAttachment(router, id, test.content)
+
+			if w.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, test.status, w.Body.String())
+			}
+			if test.wantCode != "" {
+				if response := decodeError(t, w); response.Code != test.wantCode {
+					t.Errorf("code = %q, want %q", response.Code, test.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestAttachmentSignedUrl(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	id := insertReview(t, store)
+	router := newAttachmentRouter(t, store, time.Minute)
+	w := uploadAttachment(router, id, pngImage)
+	var attachment dto.AttachmentDTO
+	if err := json.NewDecoder(w.Body).Decode(&attachment); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	w = serve(router, http.MethodGet, attachment.Url, "")
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), pngImage) {
+		t.Fatalf("status = %d, want the uploaded image", w.Code)
+	}
+
+	tampered := strings.Replace(attachment.Url, "signature=", "signature=0", 1)
+	if w := serve(router, http.MethodGet, tampered, ""); w.Code != http.StatusForbidden {
+		t.Errorf("tampered signature: status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestAttachmentUrlExpires(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	id := insertReview(t, store)
+	router := newAttachmentRouter(t, store, -time.Minute)
+	var attachment dto.AttachmentDTO
+	json.NewDecoder(uploadAttachment(router, id, pngImage).Body).Decode(&attachment)
+
+	if w := serve(router, http.MethodGet, attachment.Url, ""); w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestSubjectNamedAttachments(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	insertReviews(t, store, "attachments", domain.Host, 4)
+
+	w := serve(newAttachmentRouter(t, store, time.Minute), http.MethodGet, domain.GradeContextPath+"/attachments/host", "")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var report dto.ReviewReportDTO
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil || report.TotalReviews != 1 {
+		t.Errorf("got %d reviews (%v), want the review of the subject", report.TotalReviews, err)
+	}
+}
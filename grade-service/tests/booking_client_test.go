@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"context"
+	booking "github.com/ZMS-DevOps/booking-service/proto"
+	"github.com/mmmajder/zms-devops-grade-service/application/external"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"testing"
+)
+
+// rawCodec hands the server the request bytes as they came off the wire.
+type rawCodec struct{}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return *v.(*[]byte), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]byte) = data
+	return nil
+}
+
+func TestCheckHostHasReservationForGuest(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var method string
+	fields := make(map[protowire.Number]string)
+	server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}), grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+		method, _ = grpc.MethodFromServerStream(stream)
+		var in []byte
+		if err := stream.RecvMsg(&in); err != nil {
+			return err
+		}
+		for len(in) > 0 {
+			number, _, n := protowire.ConsumeTag(in)
+			value, m := protowire.ConsumeString(in[n:])
+			fields[number] = value
+			in = in[n+m:]
+		}
+		out, err := proto.Marshal(&booking.CheckGuestHasReservationForHostResponse{HasReservation: true})
+		if err != nil {
+			return err
+		}
+		return stream.SendMsg(&out)
+	}))
+	go server.Serve(listener)
+	defer server.Stop()
+
+	client := external.NewBookingClient(listener.Addr().String())
+	response, err := client.CheckHostHasReservationForGuest(context.Background(), &external.CheckHostHasReservationForGuestRequest{HostId: "host-1", GuestId: "guest-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if method != "/booking.BookingService/CheckHostHasReservationForGuest" {
+		t.Errorf("called %q, want CheckHostHasReservationForGuest", method)
+	}
+	if fields[1] != "host-1" || fields[2] != "guest-1" {
+		t.Errorf("sent hostId %q and guestId %q, want host-1 and guest-1", fields[1], fields[2])
+	}
+	if !response.HasReservation {
+		t.Error("hasReservation was lost decoding the response")
+	}
+}
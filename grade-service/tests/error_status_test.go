@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/api"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		wantCode string
+	}{
+		{name: "validation", err: domain.NewValidationError("invalid_grade", "grade out of range"), status: http.StatusBadRequest, wantCode: "invalid_grade"},
+		{name: "unauthorized", err: domain.NewError(domain.KindUnauthorized, "unauthorized", "missing token"), status: http.StatusUnauthorized, wantCode: "unauthorized"},
+		{name: "forbidden", err: domain.ErrNotReviewAuthor, status: http.StatusForbidden, wantCode: domain.ErrNotReviewAuthor.Code},
+		{name: "not found", err: domain.ErrNotFound, status: http.StatusNotFound, wantCode: domain.ErrNotFound.Code},
+		{name: "conflict", err: domain.ErrAlreadyReported, status: http.StatusConflict, wantCode: domain.ErrAlreadyReported.Code},
+		{name: "unprocessable", err: domain.ErrIdempotencyKeyReused, status: http.StatusUnprocessableEntity, wantCode: domain.ErrIdempotencyKeyReused.Code},
+		{name: "precondition", err: domain.NewError(domain.KindPrecondition, "version_mismatch", "stale"), status: http.StatusPreconditionFailed, wantCode: "version_mismatch"},
+		{name: "too large", err: domain.ErrAttachmentTooLarge, status: http.StatusRequestEntityTooLarge, wantCode: domain.ErrAttachmentTooLarge.Code},
+		{name: "unsupported", err: domain.ErrUnsupportedAttachmentType, status: http.StatusUnsupportedMediaType, wantCode: domain.ErrUnsupportedAttachmentType.Code},
+		{name: "rate limited", err: domain.ErrRateLimited, status: http.StatusTooManyRequests, wantCode: domain.ErrRateLimited.Code},
+		{name: "upstream", err: domain.ErrTranslationUnavailable, status: http.StatusBadGateway, wantCode: domain.ErrTranslationUnavailable.Code},
+		{name: "wrapped", err: fmt.Errorf("loading review: %w", domain.ErrNotFound), status: http.StatusNotFound, wantCode: domain.ErrNotFound.Code},
+		{name: "missing document", err: mongo.ErrNoDocuments, status: http.StatusNotFound, wantCode: domain.ErrNotFound.Code},
+		{name: "unclassified", err: errors.New("connection reset"), status: http.StatusInternalServerError, wantCode: "internal_error"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			domainErr, status := api.ErrorStatus(test.err)
+
+			if status != test.status || domainErr.Code != test.wantCode {
+				t.Errorf("got %d %q, want %d %q", status, domainErr.Code, test.status, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestMiddlewareErrorsCarryTraceId(t *testing.T) {
+	traceProvider := sdktrace.NewTracerProvider()
+	router := mux.NewRouter()
+	router.Use(api.TracingMiddleware(traceProvider))
+	router.Use(api.NewIdempotency(persistence.NewIdempotencyMemoryStore(), time.Hour, time.Minute).
+		Protect(http.MethodPost, domain.GradeContextPath).
+		Middleware)
+	api.MountVersions(router, api.Deprecation{}, api.NewReviewHandler(newReviewService(persistence.NewReviewMemoryStore(), &recordingPublisher{}, &fakeBookingClient{}), traceProvider, nopLoki{}))
+
+	w := postReview(router, strings.Repeat("k", 256), addReviewBody)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+	if response := decodeError(t, w); response.TraceId == "" {
+		t.Errorf("error %q has no trace id", response.Code)
+	}
+}
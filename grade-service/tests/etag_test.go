@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const updateReviewBody = `{"comment":"Even better the second time","grade":5,"reviewType":"host"}`
+
+func putReview(router http.Handler, id string, ifMatch string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPut, domain.GradeContextPath+"/"+id, strings.NewReader(updateReviewBody))
+	r.Header.Set(domain.JwtPayloadHeader, jwtPayload("reviewer", domain.GuestRole))
+	if ifMatch != "" {
+		r.Header.Set("If-Match", ifMatch)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func insertReview(t *testing.T, store domain.ReviewStore) string {
+	t.Helper()
+	id, err := store.Insert(&domain.Review{
+		Comment:     "Lovely host",
+		Grade:       4,
+		SubReviewer: "reviewer",
+		SubReviewed: "host-1",
+		Type:        domain.Host,
+		Status:      domain.StatusPublished,
+	})
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	return id.Hex()
+}
+
+func TestGetReviewReturnsETag(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	id := insertReview(t, store)
+
+	w := serve(newRouter(store), http.MethodGet, domain.GradeContextPath+"/"+id, "")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if etag := w.Header().Get("ETag"); etag != `"1"` {
+		t.Errorf("ETag = %s, want \"1\"", etag)
+	}
+}
+
+func TestUpdateWithIfMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		ifMatch  string
+		status   int
+		wantETag string
+	}{
+		{"without If-Match", "", http.StatusOK, `"2"`},
+		{"any version", "*", http.StatusOK, `"2"`},
+		{"current version", `"1"`, http.StatusOK, `"2"`},
+		{"one of several", `"7", "1"`, http.StatusOK, `"2"`},
+		{"stale version", `"0"`, http.StatusPreconditionFailed, ""},
+		{"weak tag", `W/"1"`, http.StatusPreconditionFailed, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			id := insertReview(t, store)
+
+			w := putReview(newRouter(store), id, test.ifMatch)
+
+			if w.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, test.status, w.Body.String())
+			}
+			if test.status != http.StatusOK {
+				if response := decodeError(t, w); response.Code != domain.ErrVersionMismatch.Code {
+					t.Errorf("code = %q, want %q", response.Code, domain.ErrVersionMismatch.Code)
+				}
+				return
+			}
+			if etag := w.Header().Get("ETag"); etag != test.wantETag {
+				t.Errorf("ETag = %s, want %s", etag, test.wantETag)
+			}
+		})
+	}
+}
+
+func TestConcurrentUpdatesWithSameVersion(t *testing.T) {
+	const writers = 8
+	store := persistence.NewReviewMemoryStore()
+	id := insertReview(t, store)
+	router := newRouter(store)
+
+	start := make(chan struct{})
+	statuses := make(chan int, writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			statuses <- putReview(router, id, `"1"`).Code
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(statuses)
+
+	counts := make(map[int]int)
+	for status := range statuses {
+		counts[status]++
+	}
+	if counts[http.StatusOK] != 1 || counts[http.StatusPreconditionFailed] != writers-1 {
+		t.Fatalf("statuses = %v, want one %d and %d %d", counts, http.StatusOK, writers-1, http.StatusPreconditionFailed)
+	}
+}
+
+func TestOnlyContentChangesIncrementVersion(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	id := insertReview(t, store)
+	objectId, _ := primitive.ObjectIDFromHex(id)
+
+	if _, err := store.SetVoteCounts(objectId, 2, 1); err != nil {
+		t.Fatalf("set vote counts: %v", err)
+	}
+	if _, err := store.UpdateStatus(objectId, domain.StatusPendingModeration); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	if review, _ := store.Get(objectId); review.Version != 1 {
+		t.Fatalf("version after votes and moderation = %d, want 1", review.Version)
+	}
+
+	review, err := store.Update(objectId, "Changed my mind", 2, "en", domain.Sentiment{}, []int64{1})
+	if err != nil {
+		t.Fatalf("update against the version before the votes: %v", err)
+	}
+	if review.Version != 2 {
+		t.Errorf("version after the edit = %d, want 2", review.Version)
+	}
+}
@@ -0,0 +1,315 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	booking "github.com/ZMS-DevOps/booking-service/proto"
+	"github.com/mmmajder/zms-devops-grade-service/application/external"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc"
+	"sync"
+)
+
+// recordingPublisher keeps every published event in order.
+type recordingPublisher struct {
+	mutex  sync.Mutex
+	events []domain.Event
+}
+
+func (publisher *recordingPublisher) Publish(ctx context.Context, event domain.Event) error {
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+	publisher.events = append(publisher.events, event)
+	return nil
+}
+
+func (publisher *recordingPublisher) Topics() []string {
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+	topics := make([]string, 0, len(publisher.events))
+	for _, event := range publisher.events {
+		topics = append(topics, event.Topic)
+	}
+	return topics
+}
+
+func (publisher *recordingPublisher) Last(topic string) (domain.Event, bool) {
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+	for i := len(publisher.events) - 1; i >= 0; i-- {
+		if publisher.events[i].Topic == topic {
+			return publisher.events[i], true
+		}
+	}
+	return domain.Event{}, false
+}
+
+// fakeBookingClient answers every reservation check with HasReservation, or
+// fails with Err, and keeps the last host and guest check it was asked. Calls
+// to other booking RPCs panic on the nil embedded client.
+type fakeBookingClient struct {
+	booking.BookingServiceClient
+	HasReservation bool
+	Err            error
+	HostRequest    *external.CheckHostHasReservationForGuestRequest
+}
+
+func (client *fakeBookingClient) CheckGuestHasReservationForHost(ctx context.Context, in *booking.CheckGuestHasReservationForHostRequest, opts ...grpc.CallOption) (*booking.CheckGuestHasReservationForHostResponse, error) {
+	if client.Err != nil {
+		return nil, client.Err
+	}
+	return &booking.CheckGuestHasReservationForHostResponse{HasReservation: client.HasReservation}, nil
+}
+
+func (client *fakeBookingClient) CheckHostHasReservationForGuest(ctx context.Context, in *external.CheckHostHasReservationForGuestRequest, opts ...grpc.CallOption) (*external.CheckHostHasReservationForGuestResponse, error) {
+	client.HostRequest = in
+	if client.Err != nil {
+		return nil, client.Err
+	}
+	return &external.CheckHostHasReservationForGuestResponse{HasReservation: client.HasReservation}, nil
+}
+
+func (client *fakeBookingClient) CheckGuestHasReservationForAccommodation(ctx context.Context, in *booking.CheckGuestHasReservationForAccommodationRequest, opts ...grpc.CallOption) (*booking.CheckGuestHasReservationForAccommodationResponse, error) {
+	if client.Err != nil {
+		return nil, client.Err
+	}
+	return &booking.CheckGuestHasReservationForAccommodationResponse{HasReservation: client.HasReservation}, nil
+}
+
+type voteStore struct {
+	mutex sync.Mutex
+	votes map[primitive.ObjectID]map[string]domain.ReviewVote
+}
+
+func newVoteStore() *voteStore {
+	return &voteStore{votes: make(map[primitive.ObjectID]map[string]domain.ReviewVote)}
+}
+
+func (store *voteStore) Upsert(vote *domain.ReviewVote) (*domain.ReviewVote, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	votes, ok := store.votes[vote.ReviewId]
+	if !ok {
+		votes = make(map[string]domain.ReviewVote)
+		store.votes[vote.ReviewId] = votes
+	}
+	previous, existed := votes[vote.Voter]
+	votes[vote.Voter] = *vote
+	if !existed {
+		return nil, nil
+	}
+	return &previous, nil
+}
+
+func (store *voteStore) Count(reviewId primitive.ObjectID) (int, int, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	var helpful, notHelpful int
+	for _, vote := range store.votes[reviewId] {
+		if vote.Helpful {
+			helpful++
+		} else {
+			notHelpful++
+		}
+	}
+	return helpful, notHelpful, nil
+}
+
+func (store *voteStore) DeleteByReview(reviewId primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.votes, reviewId)
+	return nil
+}
+
+func (store *voteStore) DeleteAll() {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.votes = make(map[primitive.ObjectID]map[string]domain.ReviewVote)
+}
+
+type reportStore struct {
+	mutex   sync.Mutex
+	reports []domain.ReviewAbuseReport
+}
+
+func (store *reportStore) Insert(report *domain.ReviewAbuseReport) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	for _, stored := range store.reports {
+		if stored.ReviewId == report.ReviewId && stored.Reporter == report.Reporter && !stored.Resolved {
+			return domain.ErrAlreadyReported
+		}
+	}
+	report.Id = primitive.NewObjectID()
+	store.reports = append(store.reports, *report)
+	return nil
+}
+
+func (store *reportStore) CountByReview(reviewId primitive.ObjectID) (int, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	count := 0
+	for _, report := range store.reports {
+		if report.ReviewId == reviewId && !report.Resolved {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (store *reportStore) GetReportedReviews() ([]*domain.ReportedReview, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	byReview := make(map[primitive.ObjectID]*domain.ReportedReview)
+	reportedReviews := make([]*domain.ReportedReview, 0)
+	for _, report := range store.reports {
+		if report.Resolved {
+			continue
+		}
+		reportedReview, ok := byReview[report.ReviewId]
+		if !ok {
+			reportedReview = &domain.ReportedReview{ReviewId: report.ReviewId}
+			byReview[report.ReviewId] = reportedReview
+			reportedReviews = append(reportedReviews, reportedReview)
+		}
+		reportedReview.TotalReports++
+	}
+	return reportedReviews, nil
+}
+
+func (store *reportStore) Resolve(reviewId primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	for i := range store.reports {
+		if store.reports[i].ReviewId == reviewId {
+			store.reports[i].Resolved = true
+		}
+	}
+	return nil
+}
+
+func (store *reportStore) DeleteAll() {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.reports = nil
+}
+
+// suspicionStore keeps the latest suspicion of every key.
+type suspicionStore struct {
+	mutex      sync.Mutex
+	suspicions map[string]*domain.Suspicion
+}
+
+func newSuspicionStore() *suspicionStore {
+	return &suspicionStore{suspicions: make(map[string]*domain.Suspicion)}
+}
+
+func (store *suspicionStore) Upsert(suspicion *domain.Suspicion) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.suspicions[suspicion.Key] = suspicion
+	return nil
+}
+
+func (store *suspicionStore) GetAll() ([]*domain.Suspicion, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	suspicions := make([]*domain.Suspicion, 0, len(store.suspicions))
+	for _, suspicion := range store.suspicions {
+		suspicions = append(suspicions, suspicion)
+	}
+	return suspicions, nil
+}
+
+func (store *suspicionStore) DeleteAll() {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.suspicions = make(map[string]*domain.Suspicion)
+}
+
+// summaryStore keeps summaries in memory and starts empty, like a fresh Mongo
+// collection.
+type summaryStore struct {
+	mutex     sync.Mutex
+	summaries map[domain.ReviewSubject]domain.ReviewSummary
+}
+
+func newSummaryStore() *summaryStore {
+	return &summaryStore{summaries: make(map[domain.ReviewSubject]domain.ReviewSummary)}
+}
+
+func (store *summaryStore) Get(subReviewed string, reviewType domain.ReviewType) (*domain.ReviewSummary, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	summary, ok := store.summaries[domain.ReviewSubject{SubReviewed: subReviewed, Type: reviewType}]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &summary, nil
+}
+
+func (store *summaryStore) Upsert(summary *domain.ReviewSummary) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.summaries[domain.ReviewSubject{SubReviewed: summary.SubReviewed, Type: summary.Type}] = *summary
+	return nil
+}
+
+func (store *summaryStore) Delete(subReviewed string, reviewType domain.ReviewType) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.summaries, domain.ReviewSubject{SubReviewed: subReviewed, Type: reviewType})
+	return nil
+}
+
+func (store *summaryStore) GetSubjects() ([]domain.ReviewSubject, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	subjects := make([]domain.ReviewSubject, 0, len(store.summaries))
+	for subject := range store.summaries {
+		subjects = append(subjects, subject)
+	}
+	return subjects, nil
+}
+
+func (store *summaryStore) DeleteAll() {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.summaries = make(map[domain.ReviewSubject]domain.ReviewSummary)
+}
+
+type versionStore struct {
+	mutex    sync.Mutex
+	versions map[string]int64
+}
+
+func newVersionStore() *versionStore {
+	return &versionStore{versions: make(map[string]int64)}
+}
+
+func (store *versionStore) Next(subReviewed string, reviewType domain.ReviewType) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	key := versionKey(subReviewed, reviewType)
+	store.versions[key]++
+	return store.versions[key], nil
+}
+
+func (store *versionStore) Get(subReviewed string, reviewType domain.ReviewType) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.versions[versionKey(subReviewed, reviewType)], nil
+}
+
+func (store *versionStore) DeleteAll() {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.versions = make(map[string]int64)
+}
+
+func versionKey(subReviewed string, reviewType domain.ReviewType) string {
+	return fmt.Sprintf("%s:%d", subReviewed, reviewType)
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/messaging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type publishedLine struct {
+	Topic   string            `json:"topic"`
+	Key     string            `json:"key"`
+	Headers map[string]string `json:"headers"`
+	Event   struct {
+		SpecVersion string          `json:"specversion"`
+		Id          string          `json:"id"`
+		Source      string          `json:"source"`
+		Type        string          `json:"type"`
+		Subject     string          `json:"subject"`
+		Time        string          `json:"time"`
+		DataSchema  string          `json:"dataschema"`
+		Data        json.RawMessage `json:"data"`
+	} `json:"event"`
+}
+
+func TestFilePublisherWritesCloudEvents(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "publish")
+	defer span.End()
+
+	path := filepath.Join(t.TempDir(), "events", "grade.ndjson")
+	publisher, err := messaging.NewFilePublisher(path)
+	if err != nil {
+		t.Fatalf("new publisher: %v", err)
+	}
+
+	events := []domain.Event{
+		{Topic: "host-rating.changed", Key: "host-1", Type: domain.RatingChangedEventType, Subject: "host-1", DataSchema: domain.RatingChangedSchema, Data: dto.RatingChangedDTO{Id: "host-1", Rating: 4.5}},
+		{Topic: "host-rating.changed", Key: "host-1", Type: domain.RatingChangedEventType, Subject: "host-1", DataSchema: domain.RatingChangedSchema, Data: dto.RatingChangedDTO{Id: "host-1", Rating: 4}},
+	}
+	for _, event := range events {
+		if err := publisher.Publish(ctx, event); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer file.Close()
+
+	ids := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	lines := 0
+	for ; scanner.Scan(); lines++ {
+		var line publishedLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("line %d is not JSON: %v", lines, err)
+		}
+		if lines >= len(events) {
+			t.Fatalf("more lines than published events")
+		}
+		want := events[lines]
+		if line.Topic != want.Topic || line.Key != want.Key {
+			t.Errorf("line %d topic %q key %q, want %q %q", lines, line.Topic, line.Key, want.Topic, want.Key)
+		}
+		if line.Event.SpecVersion != "1.0" || line.Event.Source == "" || line.Event.Time == "" {
+			t.Errorf("line %d has incomplete envelope: %+v", lines, line.Event)
+		}
+		if line.Event.Type != want.Type || line.Event.DataSchema != want.DataSchema || line.Event.Subject != want.Subject {
+			t.Errorf("line %d type %q schema %q subject %q", lines, line.Event.Type, line.Event.DataSchema, line.Event.Subject)
+		}
+		if ids[line.Event.Id] || line.Event.Id == "" {
+			t.Errorf("line %d id %q is empty or repeated", lines, line.Event.Id)
+		}
+		ids[line.Event.Id] = true
+
+		var rating dto.RatingChangedDTO
+		if err := json.Unmarshal(line.Event.Data, &rating); err != nil || rating != want.Data {
+			t.Errorf("line %d data %s, want %+v", lines, line.Event.Data, want.Data)
+		}
+		if traceparent := line.Headers["traceparent"]; traceparent == "" || traceparent[3:35] != span.SpanContext().TraceID().String() {
+			t.Errorf("line %d traceparent %q does not carry trace %s", lines, traceparent, span.SpanContext().TraceID())
+		}
+	}
+	if lines != len(events) {
+		t.Errorf("%d lines, want %d", lines, len(events))
+	}
+}
@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"fmt"
+	"github.com/mmmajder/zms-devops-grade-service/application"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+const copiedComment = "The apartment was spotless and the host met us at the station with a map of the city"
+
+type seededReview struct {
+	subject   string
+	reviewer  string
+	grade     float32
+	comment   string
+	at        time.Time
+	accountAt time.Time
+	abusive   bool
+}
+
+func seed(t *testing.T, store domain.ReviewStore, reviews []seededReview) []primitive.ObjectID {
+	t.Helper()
+	ids := make([]primitive.ObjectID, 0, len(reviews))
+	for _, review := range reviews {
+		if review.subject == "" {
+			review.subject = "host-1"
+		}
+		if review.comment == "" {
+			review.comment = "Stayed for a weekend"
+		}
+		id, err := store.Insert(&domain.Review{
+			Id:                primitive.NewObjectIDFromTimestamp(review.at),
+			Comment:           review.comment,
+			Grade:             review.grade,
+			SubReviewer:       review.reviewer,
+			SubReviewed:       review.subject,
+			Type:              domain.Host,
+			Status:            domain.StatusPublished,
+			ReviewerCreatedAt: review.accountAt,
+		})
+		if err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// steadyTraffic is a popular host reviewed twice an hour for 100 hours with
+// one busy hour of ten reviews.
+func steadyTraffic(now time.Time) []seededReview {
+	var reviews []seededReview
+	for i := 0; i < 200; i++ {
+		reviews = append(reviews, seededReview{reviewer: fmt.Sprintf("guest-%d", i), grade: float32(4 + i%2), at: now.Add(-100*time.Hour + time.Duration(i)*30*time.Minute)})
+	}
+	for i := 0; i < 7; i++ {
+		reviews = append(reviews, seededReview{reviewer: fmt.Sprintf("busy-%d", i), grade: 5, at: now.Add(-50*time.Hour + time.Duration(i)*5*time.Minute)})
+	}
+	return reviews
+}
+
+// regulars always give five stars, here to a host everyone likes.
+func regulars(now time.Time) []seededReview {
+	var reviews []seededReview
+	for r := 0; r < 4; r++ {
+		reviewer := fmt.Sprintf("regular-%d", r)
+		for j := 0; j < 7; j++ {
+			reviews = append(reviews, seededReview{subject: fmt.Sprintf("host-%d-%d", r, j), reviewer: reviewer, grade: 5, at: now.Add(-time.Duration(30+j) * 24 * time.Hour)})
+		}
+		reviews = append(reviews, seededReview{reviewer: reviewer, grade: 5, at: now.Add(-time.Duration(r+1) * 24 * time.Hour)})
+	}
+	for i, grade := range []float32{4, 5, 4} {
+		reviews = append(reviews, seededReview{reviewer: fmt.Sprintf("guest-%d", i), grade: grade, at: now.Add(-time.Duration(i+5) * 24 * time.Hour)})
+	}
+	return reviews
+}
+
+// recentReviews are four reviews of the last day whose accounts were
+// created at accountAt.
+func recentReviews(now time.Time, accountAt time.Time, abusive bool) []seededReview {
+	reviews := []seededReview{
+		{reviewer: "guest-old-1", grade: 4, at: now.Add(-30 * 24 * time.Hour)},
+		{reviewer: "guest-old-2", grade: 4, at: now.Add(-20 * 24 * time.Hour)},
+	}
+	for i := 0; i < 4; i++ {
+		reviews = append(reviews, seededReview{reviewer: fmt.Sprintf("guest-%d", i), grade: 5, at: now.Add(-time.Duration(2*i+1) * time.Hour), accountAt: accountAt, abusive: abusive})
+	}
+	return reviews
+}
+
+func paidBurst(now time.Time) []seededReview {
+	var reviews []seededReview
+	for i := 0; i < 20; i++ {
+		reviews = append(reviews, seededReview{reviewer: fmt.Sprintf("guest-%d", i), grade: 4, at: now.Add(-200*time.Hour + time.Duration(i)*10*time.Hour)})
+	}
+	for i := 0; i < 12; i++ {
+		reviews = append(reviews, seededReview{reviewer: fmt.Sprintf("paid-%d", i), grade: 5, at: now.Add(-time.Hour + time.Duration(i)*2*time.Minute), abusive: true})
+	}
+	return reviews
+}
+
+func extremeShill(now time.Time) []seededReview {
+	var reviews []seededReview
+	for j := 0; j < 5; j++ {
+		reviews = append(reviews, seededReview{subject: fmt.Sprintf("rival-%d", j), reviewer: "shill", grade: 1, at: now.Add(-time.Duration(10+j) * 24 * time.Hour)})
+	}
+	for i := 0; i < 4; i++ {
+		reviews = append(reviews, seededReview{reviewer: fmt.Sprintf("guest-%d", i), grade: 5, at: now.Add(-time.Duration(i+2) * 24 * time.Hour)})
+	}
+	return append(reviews, seededReview{reviewer: "shill", grade: 1, at: now.Add(-24 * time.Hour), abusive: true})
+}
+
+func copiedComments(now time.Time) []seededReview {
+	return []seededReview{
+		{reviewer: "guest-1", grade: 4, at: now.Add(-72 * time.Hour)},
+		{reviewer: "guest-2", grade: 5, at: now.Add(-48 * time.Hour), comment: copiedComment, abusive: true},
+		{reviewer: "guest-3", grade: 5, at: now.Add(-24 * time.Hour), comment: copiedComment, abusive: true},
+	}
+}
+
+func TestFraudDetection(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		reviews []seededReview
+	}{
+		{"steady traffic with a busy hour", steadyTraffic(now)},
+		{"regulars who always give five stars", regulars(now)},
+		{"recent reviews from established accounts", recentReviews(now, now.Add(-2*365*24*time.Hour), false)},
+		{"recent reviews with unknown account ages", recentReviews(now, time.Time{}, false)},
+		{"paid burst", paidBurst(now)},
+		{"extreme shill", extremeShill(now)},
+		{"copied comments", copiedComments(now)},
+		{"fresh accounts", recentReviews(now, now.Add(-48*time.Hour), true)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			settings := fraudSettings()
+			settings.AutoHide = true
+			detector := application.NewFraudDetector(store, newSuspicionStore(), settings)
+			service := newReviewServiceWithDetector(store, &recordingPublisher{}, &fakeBookingClient{}, detector)
+			moderation := application.NewModerationService(service, store, &reportStore{}, 3)
+			ids := seed(t, store, test.reviews)
+
+			if err := moderation.InspectAll(span, nopLoki{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for i, review := range test.reviews {
+				want := domain.StatusPublished
+				if review.abusive {
+					want = domain.StatusPendingModeration
+				}
+				if status := reviewStatus(t, store, ids[i]); status != want {
+					t.Errorf("review by %s of %s: status = %q, want %q", review.reviewer, review.subject, status, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFraudDetectionRecordsWithoutAutoHide(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	suspicions := newSuspicionStore()
+	service := newReviewServiceWithDetector(store, &recordingPublisher{}, &fakeBookingClient{}, application.NewFraudDetector(store, suspicions, fraudSettings()))
+	moderation := application.NewModerationService(service, store, &reportStore{}, 3)
+	ids := seed(t, store, copiedComments(time.Now()))
+
+	if err := moderation.InspectAll(span, nopLoki{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if recorded, _ := suspicions.GetAll(); len(recorded) != 1 || recorded[0].Kind != domain.SuspicionNearDuplicate {
+		t.Errorf("recorded %v, want the near duplicate", recorded)
+	}
+	for i, id := range ids {
+		review, err := store.Get(id)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if review.Status != domain.StatusPublished {
+			t.Errorf("status = %q, want reviews left published", review.Status)
+		}
+		if wantFlagged := i > 0; review.Flagged != wantFlagged {
+			t.Errorf("review by %s: flagged = %t, want %t", review.SubReviewer, review.Flagged, wantFlagged)
+		}
+	}
+}
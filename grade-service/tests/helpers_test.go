@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/application"
+	"github.com/mmmajder/zms-devops-grade-service/application/external"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/api"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/translation"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type nopLoki struct{}
+
+func (nopLoki) Debugf(format string, args ...interface{}) {}
+func (nopLoki) Infof(format string, args ...interface{})  {}
+func (nopLoki) Warnf(format string, args ...interface{})  {}
+func (nopLoki) Errorf(format string, args ...interface{}) {}
+func (nopLoki) Shutdown()                                 {}
+
+var _ promtail.Client = nopLoki{}
+
+var testTopics = application.ReviewTopics{
+	HostRatingChanged:          "host-rating.changed",
+	HostReviewCreated:          "host-review.created",
+	AccommodationRatingChanged: "accommodation-rating.changed",
+	AccommodationReviewCreated: "accommodation-review.created",
+	GuestRatingChanged:         "guest-rating.changed",
+}
+
+func defaultReviewTypes() *application.ReviewTypeRegistry {
+	registry, err := application.DefaultReviewTypeRegistry(testTopics)
+	if err != nil {
+		panic(err)
+	}
+	return registry
+}
+
+// fraudSettings are the detector defaults the server starts with.
+func fraudSettings() application.FraudDetectionSettings {
+	return application.FraudDetectionSettings{
+		BurstWindow:           time.Hour,
+		BurstThreshold:        5,
+		ExtremeMinReviews:     3,
+		DuplicateSimilarity:   0.8,
+		FreshAccountAge:       7 * 24 * time.Hour,
+		FreshAccountThreshold: 3,
+		HideScore:             0.9,
+	}
+}
+
+// newReviewService builds the service on in-memory stores with the fraud
+// detector the server uses.
+func newReviewService(store domain.ReviewStore, publisher domain.EventPublisher, bookingClient external.BookingServiceClient) *application.ReviewService {
+	return newReviewServiceWithDetector(store, publisher, bookingClient, application.NewFraudDetector(store, newSuspicionStore(), fraudSettings()))
+}
+
+func newReviewServiceWithDetector(store domain.ReviewStore, publisher domain.EventPublisher, bookingClient external.BookingServiceClient, detector *application.FraudDetector) *application.ReviewService {
+	attachments := application.NewAttachmentService(store, nil, application.AttachmentLimits{}, []byte("test-key"), time.Minute)
+	aspects := application.NewAspectService(store, newSummaryStore())
+	return application.NewReviewService(store, newVoteStore(), newVersionStore(), nil, publisher, bookingClient, defaultReviewTypes(), attachments, aspects, detector, translation.NewNoopTranslator(), nopLoki{})
+}
+
+// newRouter wires the review and moderation handlers the way the server does,
+// on top of the given store.
+func newRouter(store domain.ReviewStore) *mux.Router {
+	traceProvider := sdktrace.NewTracerProvider()
+	loki := nopLoki{}
+	reviewService := newReviewService(store, &recordingPublisher{}, &fakeBookingClient{HasReservation: true})
+	moderationService := application.NewModerationService(reviewService, store, &reportStore{}, 3)
+
+	router := mux.NewRouter()
+	api.MountVersions(router, api.Deprecation{}, api.NewModerationHandler(moderationService, traceProvider, loki), api.NewReviewHandler(reviewService, traceProvider, loki))
+	return router
+}
+
+func serve(router http.Handler, method string, path string, body string, roles ...string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	r := httptest.NewRequest(method, path, reader)
+	if len(roles) > 0 {
+		r.Header.Set(domain.JwtPayloadHeader, jwtPayload("reviewer", roles...))
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func jwtPayload(subject string, roles ...string) string {
+	claims := map[string]interface{}{
+		"sub":          subject,
+		"realm_access": map[string]interface{}{"roles": roles},
+	}
+	payload, _ := json.Marshal(claims)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// decodeError fails the test unless the body is exactly one JSON error.
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) dto.ErrorResponse {
+	t.Helper()
+	decoder := json.NewDecoder(w.Body)
+	var response dto.ErrorResponse
+	if err := decoder.Decode(&response); err != nil {
+		t.Fatalf("error body is not JSON: %v", err)
+	}
+	if decoder.More() {
+		t.Fatalf("response has more than one body")
+	}
+	return response
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/api"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const addReviewBody = `{"comment":"Lovely host","grade":5,"subReviewer":"reviewer","subReviewed":"host-1","reviewType":"host"}`
+
+func newIdempotentRouter(store domain.ReviewStore, publisher *recordingPublisher) *mux.Router {
+	return newIdempotentRouterWithStore(store, publisher, persistence.NewIdempotencyMemoryStore())
+}
+
+func newIdempotentRouterWithStore(store domain.ReviewStore, publisher *recordingPublisher, idempotencyStore domain.IdempotencyStore) *mux.Router {
+	reviewService := newReviewService(store, publisher, &fakeBookingClient{HasReservation: true})
+	router := mux.NewRouter()
+	router.Use(api.NewIdempotency(idempotencyStore, time.Hour, time.Minute).
+		Protect(http.MethodPost, domain.GradeContextPath).
+		Middleware)
+	api.MountVersions(router, api.Deprecation{}, api.NewReviewHandler(reviewService, sdktrace.NewTracerProvider(), nopLoki{}))
+	return router
+}
+
+func postReview(router http.Handler, key string, body string) *httptest.ResponseRecorder {
+	return postReviewTo(router, domain.GradeContextPath, key, body)
+}
+
+func postReviewTo(router http.Handler, path string, key string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	r.Header.Set(domain.JwtPayloadHeader, jwtPayload("reviewer", domain.GuestRole))
+	if key != "" {
+		r.Header.Set(domain.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotentRetryReplaysResponse(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	publisher := &recordingPublisher{}
+	router := newIdempotentRouter(store, publisher)
+
+	first := postReview(router, "retry-1", addReviewBody)
+	second := postReview(router, "retry-1", addReviewBody)
+
+	if first.Code != http.StatusCreated || second.Code != http.StatusCreated {
+		t.Fatalf("statuses = %d, %d, want %d twice", first.Code, second.Code, http.StatusCreated)
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Errorf("replayed body %s, want %s", second.Body, first.Body)
+	}
+	if second.Header().Get(domain.IdempotentReplayedHeader) != "true" || first.Header().Get(domain.IdempotentReplayedHeader) != "" {
+		t.Errorf("only the retry should be marked as replayed")
+	}
+	if reviews, _ := store.GetAllBySubReviewed("host-1", domain.Host); len(reviews) != 1 {
+		t.Errorf("stored %d reviews, want 1", len(reviews))
+	}
+	if topics := publisher.Topics(); len(topics) != 2 {
+		t.Errorf("published %v, want one rating change and one notification", topics)
+	}
+}
+
+func TestIdempotencyKeyWithDifferentPayload(t *testing.T) {
+	router := newIdempotentRouter(persistence.NewReviewMemoryStore(), &recordingPublisher{})
+
+	postReview(router, "retry-1", addReviewBody)
+	w := postReview(router, "retry-1", strings.Replace(addReviewBody, `"grade":5`, `"grade":1`, 1))
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if response := decodeError(t, w); response.Code != domain.ErrIdempotencyKeyReused.Code {
+		t.Errorf("code = %q, want %q", response.Code, domain.ErrIdempotencyKeyReused.Code)
+	}
+}
+
+func TestRequestsWithoutIdempotencyKeyAreNotReplayed(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	router := newIdempotentRouter(store, &recordingPublisher{})
+
+	postReview(router, "", addReviewBody)
+	postReview(router, "", addReviewBody)
+
+	if reviews, _ := store.GetAllBySubReviewed("host-1", domain.Host); len(reviews) != 2 {
+		t.Errorf("stored %d reviews, want 2", len(reviews))
+	}
+}
+
+func TestIdempotentRetryOnAnotherVersionPrefix(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	router := newIdempotentRouter(store, &recordingPublisher{})
+
+	first := postReviewTo(router, domain.GradeContextPath, "retry-1", addReviewBody)
+	second := postReviewTo(router, domain.GradeV1ContextPath, "retry-1", addReviewBody)
+
+	if first.Code != http.StatusCreated || second.Code != http.StatusCreated {
+		t.Fatalf("statuses = %d, %d, want %d twice", first.Code, second.Code, http.StatusCreated)
+	}
+	if second.Header().Get(domain.IdempotentReplayedHeader) != "true" {
+		t.Errorf("the retry on %s should be replayed", domain.GradeV1ContextPath)
+	}
+	if reviews, _ := store.GetAllBySubReviewed("host-1", domain.Host); len(reviews) != 1 {
+		t.Errorf("stored %d reviews, want 1", len(reviews))
+	}
+}
+
+// abandonedIdempotencyStore reserves keys until expiresAt and never completes
+// them, as if the replica handling the request died.
+type abandonedIdempotencyStore struct {
+	domain.IdempotencyStore
+	expiresAt time.Time
+}
+
+func (store *abandonedIdempotencyStore) Reserve(request *domain.IdempotentRequest) (*domain.IdempotentRequest, error) {
+	reserved := *request
+	reserved.ExpiresAt = store.expiresAt
+	return store.IdempotencyStore.Reserve(&reserved)
+}
+
+func (store *abandonedIdempotencyStore) Complete(string, domain.IdempotentResponse, time.Time) error {
+	return nil
+}
+
+func TestIdempotencyReservationLease(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		status    int
+	}{
+		{name: "in progress", expiresAt: time.Now().Add(time.Minute), status: http.StatusConflict},
+		{name: "lease expired", expiresAt: time.Now().Add(-time.Second), status: http.StatusCreated},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			idempotencyStore := &abandonedIdempotencyStore{IdempotencyStore: persistence.NewIdempotencyMemoryStore(), expiresAt: test.expiresAt}
+			router := newIdempotentRouterWithStore(persistence.NewReviewMemoryStore(), &recordingPublisher{}, idempotencyStore)
+			postReview(router, "retry-1", addReviewBody)
+
+			w := postReview(router, "retry-1", addReviewBody)
+
+			if w.Code != test.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, test.status, w.Body.String())
+			}
+		})
+	}
+}
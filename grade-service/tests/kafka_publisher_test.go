@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"context"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/messaging"
+	"testing"
+	"time"
+)
+
+func newProducer(t *testing.T, config kafka.ConfigMap) *kafka.Producer {
+	t.Helper()
+	producer, err := kafka.NewProducer(&config)
+	if err != nil {
+		t.Fatalf("producer: %v", err)
+	}
+	t.Cleanup(producer.Close)
+	return producer
+}
+
+func reviewEvent() domain.Event {
+	return domain.Event{Topic: "grade.review", Key: "subject", Type: "review.created", Subject: "review", Data: map[string]string{"id": "1"}}
+}
+
+func TestKafkaPublisherWaitsForDelivery(t *testing.T) {
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("mock cluster: %v", err)
+	}
+	defer cluster.Close()
+	producer := newProducer(t, kafka.ConfigMap{"bootstrap.servers": cluster.BootstrapServers()})
+
+	if err := messaging.NewKafkaPublisher(producer).Publish(context.Background(), reviewEvent()); err != nil {
+		t.Errorf("publish: %v", err)
+	}
+}
+
+func TestKafkaPublisherFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  kafka.ConfigMap
+		timeout time.Duration
+	}{
+		{name: "failed delivery report", config: kafka.ConfigMap{"message.timeout.ms": 100}, timeout: 10 * time.Second},
+		{name: "delivery timeout", config: kafka.ConfigMap{"message.timeout.ms": 60000}, timeout: 100 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config["bootstrap.servers"] = "127.0.0.1:1"
+			publisher := messaging.NewKafkaPublisherWithTimeout(newProducer(t, test.config), test.timeout)
+
+			if err := publisher.Publish(context.Background(), reviewEvent()); err == nil {
+				t.Error("publish succeeded without a broker")
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"testing"
+)
+
+func TestUpdateLanguage(t *testing.T) {
+	tests := []struct {
+		name         string
+		comment      string
+		wantLanguage string
+	}{
+		{"same comment", "Stayed for a weekend", "fr"},
+		{"undetectable comment", "Ok", "fr"},
+		{"detectable comment", "The apartment was clean and the host was very friendly", "en"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			service := newReviewService(store, &recordingPublisher{}, &fakeBookingClient{})
+			id := insertReviews(t, store, "host-1", domain.Host, 4)[0]
+			if _, err := store.Update(id, "Stayed for a weekend", 4, "fr", domain.Sentiment{}, nil); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+
+			review, err := service.Update(id, test.comment, 4, nil, span, nopLoki{})
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if review.Language != test.wantLanguage {
+				t.Errorf("language = %q, want %q", review.Language, test.wantLanguage)
+			}
+		})
+	}
+}
+
+func TestLanguageFilter(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	service := newReviewService(store, &recordingPublisher{}, &fakeBookingClient{})
+	ids := insertReviews(t, store, "host-1", domain.Host, 5, 4, 3)
+	if _, err := store.Update(ids[0], "Sve je bilo odlično", 5, "sr", domain.Sentiment{}, nil); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	report, err := service.GetAllBySubReviewed("host-1", domain.Host, domain.ReviewFilter{Language: "sr"}, domain.SortUnordered, "en", span, nopLoki{})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.TotalReviews != 3 || report.MatchingReviews != 1 || len(report.Reviews) != 1 {
+		t.Fatalf("got %d of %d matching reviews listed out of %d, want 1 of 1 out of 3", len(report.Reviews), report.MatchingReviews, report.TotalReviews)
+	}
+	if report.Reviews[0].TranslatedComment != "" {
+		t.Errorf("translatedComment = %q, want none without a translation backend", report.Reviews[0].TranslatedComment)
+	}
+}
@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/api"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/metrics"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"net/http"
+	"testing"
+)
+
+func TestMetricsRecordUnmatchedRequests(t *testing.T) {
+	router := newRouter(persistence.NewReviewMemoryStore())
+	router.Use(api.MetricsMiddleware)
+	api.InstrumentUnmatched(router)
+	router.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status string
+	}{
+		{name: "unknown path", method: http.MethodGet, path: "/unknown", status: "404"},
+		{name: "unknown method", method: http.MethodPost, path: "/metrics", status: "405"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			counter := metrics.HttpRequests.WithLabelValues("unmatched", test.method, test.status)
+			before := testutil.ToFloat64(counter)
+
+			w := serve(router, test.method, test.path, "")
+
+			if w.Code == http.StatusOK {
+				t.Fatalf("status = %d, want an unmatched request", w.Code)
+			}
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("unmatched requests recorded = %v, want 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"errors"
+	"github.com/mmmajder/zms-devops-grade-service/application"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"testing"
+)
+
+// statusFailingStore fails every status change, as if Mongo went away
+// between storing a report and hiding the review.
+type statusFailingStore struct {
+	domain.ReviewStore
+}
+
+func (statusFailingStore) UpdateStatus(id primitive.ObjectID, status domain.ReviewStatus) (*domain.Review, error) {
+	return nil, errors.New("connection reset")
+}
+
+func report(t *testing.T, service *application.ModerationService, id primitive.ObjectID, reporters ...string) {
+	t.Helper()
+	for _, reporter := range reporters {
+		if err := service.Report(id, reporter, domain.ReasonSpam, "", span, nopLoki{}); err != nil {
+			t.Fatalf("report by %s: unexpected error: %v", reporter, err)
+		}
+	}
+}
+
+func reviewStatus(t *testing.T, store domain.ReviewStore, id primitive.ObjectID) domain.ReviewStatus {
+	t.Helper()
+	review, err := store.Get(id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	return review.Status
+}
+
+func TestReportThresholdHidesReview(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	service := application.NewModerationService(newReviewService(store, &recordingPublisher{}, &fakeBookingClient{}), store, &reportStore{}, 3)
+	id := insertReviews(t, store, "host-1", domain.Host, 4)[0]
+
+	report(t, service, id, "reporter-1", "reporter-2")
+	if status := reviewStatus(t, store, id); status != domain.StatusPublished {
+		t.Fatalf("status after 2 reports = %q, want %q", status, domain.StatusPublished)
+	}
+
+	report(t, service, id, "reporter-3")
+	if status := reviewStatus(t, store, id); status != domain.StatusPendingModeration {
+		t.Errorf("status after 3 reports = %q, want %q", status, domain.StatusPendingModeration)
+	}
+}
+
+func TestReportIsKeptWhenHidingFails(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	reports := &reportStore{}
+	id := insertReviews(t, store, "host-1", domain.Host, 4)[0]
+	failing := statusFailingStore{store}
+	service := application.NewModerationService(newReviewService(failing, &recordingPublisher{}, &fakeBookingClient{}), failing, reports, 1)
+
+	if err := service.Report(id, "reporter-1", domain.ReasonSpam, "", span, nopLoki{}); err != nil {
+		t.Fatalf("err = %v, want the stored report to succeed", err)
+	}
+	if count, _ := reports.CountByReview(id); count != 1 {
+		t.Fatalf("stored %d reports, want 1", count)
+	}
+
+	recovered := application.NewModerationService(newReviewService(store, &recordingPublisher{}, &fakeBookingClient{}), store, reports, 1)
+	if err := recovered.HideReported(span, nopLoki{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status := reviewStatus(t, store, id); status != domain.StatusPendingModeration {
+		t.Errorf("status = %q, want the reported review hidden", status)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	reports := &reportStore{}
+	service := application.NewModerationService(newReviewService(store, &recordingPublisher{}, &fakeBookingClient{}), store, reports, 3)
+	id := insertReviews(t, store, "host-1", domain.Host, 4)[0]
+	report(t, service, id, "reporter-1", "reporter-2", "reporter-3")
+
+	if _, err := service.Restore(id, span, nopLoki{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if status := reviewStatus(t, store, id); status != domain.StatusPublished {
+		t.Errorf("status = %q, want %q", status, domain.StatusPublished)
+	}
+	if reported, _ := service.GetReportedReviews(span, nopLoki{}); len(reported) != 0 {
+		t.Errorf("restored review is still listed as reported")
+	}
+	report(t, service, id, "reporter-4")
+	if status := reviewStatus(t, store, id); status != domain.StatusPublished {
+		t.Errorf("one new report hid the restored review again")
+	}
+}
+
+func TestReportAgainAfterRestore(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	reports := &reportStore{}
+	service := application.NewModerationService(newReviewService(store, &recordingPublisher{}, &fakeBookingClient{}), store, reports, 3)
+	id := insertReviews(t, store, "host-1", domain.Host, 4)[0]
+	report(t, service, id, "reporter-1", "reporter-2", "reporter-3")
+	if _, err := service.Restore(id, span, nopLoki{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report(t, service, id, "reporter-1")
+
+	if count, _ := reports.CountByReview(id); count != 1 {
+		t.Errorf("counted %d open reports, want the new one", count)
+	}
+	if err := service.Report(id, "reporter-1", domain.ReasonSpam, "", span, nopLoki{}); !errors.Is(err, domain.ErrAlreadyReported) {
+		t.Errorf("err = %v, want %v for a second open report", err, domain.ErrAlreadyReported)
+	}
+}
+
+func TestRestoreEndpoint(t *testing.T) {
+	tests := []struct {
+		name   string
+		role   string
+		status int
+	}{
+		{"admin", domain.AdminRole, http.StatusOK},
+		{"guest", domain.GuestRole, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			id := insertReviews(t, store, "host-1", domain.Host, 4)[0]
+			if _, err := store.UpdateStatus(id, domain.StatusPendingModeration); err != nil {
+				t.Fatalf("update status: %v", err)
+			}
+
+			w := serve(newRouter(store), http.MethodPost, domain.GradeContextPath+"/"+id.Hex()+"/restore", "", test.role)
+
+			if w.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, test.status, w.Body.String())
+			}
+			wantStatus := domain.StatusPendingModeration
+			if test.status == http.StatusOK {
+				wantStatus = domain.StatusPublished
+			}
+			if status := reviewStatus(t, store, id); status != wantStatus {
+				t.Errorf("review status = %q, want %q", status, wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/application"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/api"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// newDocumentedRouter registers every /grade handler in the order the server
+// does, behind the request validator.
+func newDocumentedRouter(t *testing.T, store domain.ReviewStore) *mux.Router {
+	t.Helper()
+	validator, err := api.NewRequestValidator()
+	if err != nil {
+		t.Fatalf("load OpenAPI document: %v", err)
+	}
+	traceProvider := sdktrace.NewTracerProvider()
+	reviewService := newReviewService(store, &recordingPublisher{}, &fakeBookingClient{HasReservation: true})
+	attachmentService := application.NewAttachmentService(store, nil, application.AttachmentLimits{}, []byte("test-key"), time.Minute)
+
+	router := mux.NewRouter()
+	router.Use(validator.Middleware)
+	api.MountVersions(router, api.Deprecation{},
+		api.NewOpenAPIHandler(),
+		api.NewAttachmentHandler(attachmentService, traceProvider, nopLoki{}),
+		api.NewModerationHandler(application.NewModerationService(reviewService, store, &reportStore{}, 3), traceProvider, nopLoki{}),
+		api.NewReviewHandler(reviewService, traceProvider, nopLoki{}))
+	return router
+}
+
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	router := newDocumentedRouter(t, persistence.NewReviewMemoryStore())
+
+	// Each prefix serves a document that describes its own paths.
+	registered := map[string]map[string]bool{domain.GradeContextPath: {}, domain.GradeV1ContextPath: {}, domain.GradeV2ContextPath: {}}
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		pathTemplate, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		prefix := domain.GradeContextPath
+		for _, versioned := range []string{domain.GradeV1ContextPath, domain.GradeV2ContextPath} {
+			if pathTemplate == versioned || strings.HasPrefix(pathTemplate, versioned+"/") {
+				prefix = versioned
+			}
+		}
+		methods, _ := route.GetMethods()
+		for _, method := range methods {
+			registered[prefix][method+" "+pathTemplate] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+
+	for prefix, routes := range registered {
+		w := serve(router, http.MethodGet, prefix+"/openapi.json", "")
+		var document struct {
+			Paths map[string]map[string]json.RawMessage `json:"paths"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&document); err != nil {
+			t.Fatalf("%s OpenAPI document is not JSON: %v", prefix, err)
+		}
+		documented := make(map[string]bool)
+		for path, operations := range document.Paths {
+			for method := range operations {
+				documented[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+
+		if missing := difference(routes, documented); len(missing) > 0 {
+			t.Errorf("%s routes missing from the OpenAPI document: %v", prefix, missing)
+		}
+		if stale := difference(documented, routes); len(stale) > 0 {
+			t.Errorf("%s documented operations without a route: %v", prefix, stale)
+		}
+	}
+}
+
+func TestSwaggerUIServed(t *testing.T) {
+	router := newDocumentedRouter(t, persistence.NewReviewMemoryStore())
+
+	for _, prefix := range []string{domain.GradeContextPath, domain.GradeV1ContextPath, domain.GradeV2ContextPath} {
+		w := serve(router, http.MethodGet, prefix+"/docs", "")
+
+		if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get(domain.ContentType), "text/html") {
+			t.Fatalf("%s: status = %d and Content-Type %q, want an HTML page", prefix, w.Code, w.Header().Get(domain.ContentType))
+		}
+		if !strings.Contains(w.Body.String(), `"`+prefix+`/openapi.json"`) {
+			t.Errorf("%s: page does not load the OpenAPI document of its version", prefix)
+		}
+		for _, asset := range []string{"swagger-ui.css", "swagger-ui-bundle.js"} {
+			if !strings.Contains(w.Body.String(), `"`+prefix+`/docs/`+asset+`"`) {
+				t.Errorf("%s: page does not load %s from the service", prefix, asset)
+			}
+		}
+		if strings.Contains(w.Body.String(), "https://") {
+			t.Errorf("%s: page loads assets from another origin", prefix)
+		}
+	}
+}
+
+func TestSwaggerUIAssetNotFound(t *testing.T) {
+	router := newDocumentedRouter(t, persistence.NewReviewMemoryStore())
+
+	w := serve(router, http.MethodGet, domain.GradeContextPath+"/docs/README.md", "")
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d for a file that is not a Swagger UI asset", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+		wantCode    string
+		wantFields  []string
+	}{
+		{name: "valid review", method: http.MethodPost, path: "", body: addReviewBody, status: http.StatusCreated},
+		{name: "missing fields", method: http.MethodPost, path: "", body: `{"comment":"Lovely host"}`, status: http.StatusBadRequest, wantCode: "invalid_request", wantFields: []string{"grade", "subReviewer", "subReviewed"}},
+		{name: "wrong types", method: http.MethodPost, path: "", body: `{"comment":5,"grade":"five","subReviewer":"a","subReviewed":"b","reviewType":true}`, status: http.StatusBadRequest, wantCode: "invalid_request", wantFields: []string{"comment", "grade", "reviewType"}},
+		{name: "not JSON", method: http.MethodPost, path: "", body: `{"comment":`, status: http.StatusBadRequest, wantCode: "invalid_payload"},
+		{name: "unsupported content type", method: http.MethodPost, path: "", contentType: "text/plain", body: addReviewBody, status: http.StatusUnsupportedMediaType, wantCode: "unsupported_media_type"},
+		{name: "merge patch content type", method: http.MethodPatch, path: "/{id}", contentType: "application/merge-patch+json", body: `{"grade":3}`, status: http.StatusOK},
+		{name: "zero grade patch", method: http.MethodPatch, path: "/{id}", body: `{"grade":0}`, status: http.StatusBadRequest, wantCode: "invalid_request", wantFields: []string{"grade"}},
+		{name: "unknown patch member", method: http.MethodPatch, path: "/{id}", body: `{"grade":3,"subReviewed":"other"}`, status: http.StatusBadRequest, wantCode: "invalid_request", wantFields: []string{"subReviewed"}},
+		{name: "patch that is not an object", method: http.MethodPatch, path: "/{id}", body: `[]`, status: http.StatusBadRequest, wantCode: "invalid_payload"},
+		{name: "invalid report reason", method: http.MethodPost, path: "/{id}/report", body: `{"reason":"boring"}`, status: http.StatusBadRequest, wantCode: "invalid_request", wantFields: []string{"reason"}},
+		{name: "malformed id", method: http.MethodGet, path: "/not-an-id", status: http.StatusBadRequest, wantCode: "invalid_id"},
+		{name: "malformed id of a vote", method: http.MethodPost, path: "/not-an-id/helpful", status: http.StatusBadRequest, wantCode: "invalid_id"},
+		{name: "search limit", method: http.MethodGet, path: "/host-1/host/search?q=clean&limit=many", status: http.StatusBadRequest, wantCode: "invalid_request", wantFields: []string{"limit"}},
+		{name: "search without query", method: http.MethodGet, path: "/host-1/host/search", status: http.StatusBadRequest, wantCode: "invalid_request", wantFields: []string{"q"}},
+		{name: "sort order", method: http.MethodGet, path: "/host-1/host?sort=oldest", status: http.StatusBadRequest, wantCode: "invalid_request", wantFields: []string{"sort"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			id := insertReview(t, store)
+			path := domain.GradeContextPath + strings.Replace(test.path, "{id}", id, 1)
+			var r *http.Request
+			if test.body == "" {
+				r = httptest.NewRequest(test.method, path, nil)
+			} else {
+				r = httptest.NewRequest(test.method, path, strings.NewReader(test.body))
+			}
+			r.Header.Set(domain.JwtPayloadHeader, jwtPayload("reviewer", domain.GuestRole))
+			if test.contentType != "" {
+				r.Header.Set(domain.ContentType, test.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			newDocumentedRouter(t, store).ServeHTTP(w, r)
+
+			if w.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, test.status, w.Body.String())
+			}
+			if test.wantCode == "" {
+				return
+			}
+			response := decodeError(t, w)
+			if response.Code != test.wantCode {
+				t.Errorf("code = %q, want %q", response.Code, test.wantCode)
+			}
+			fields := make([]string, 0, len(response.Details))
+			for _, detail := range response.Details {
+				fields = append(fields, detail.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(test.wantFields, ",") {
+				t.Errorf("fields = %v, want %v", fields, test.wantFields)
+			}
+		})
+	}
+}
+
+func difference(left map[string]bool, right map[string]bool) []string {
+	missing := make([]string, 0)
+	for key := range left {
+		if !right[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
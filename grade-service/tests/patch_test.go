@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"net/http"
+	"testing"
+)
+
+func TestPatch(t *testing.T) {
+	comment := "Spotless and quiet, would stay again"
+	grade := float32(5)
+	sameGrade := float32(4)
+	shortComment := "Ok"
+	language := "de"
+	tests := []struct {
+		name          string
+		patch         domain.ReviewPatch
+		wantComment   string
+		wantGrade     float32
+		wantLanguage  string
+		ratingChanged bool
+	}{
+		{name: "comment only", patch: domain.ReviewPatch{Comment: &comment}, wantComment: comment, wantGrade: 4, wantLanguage: "en"},
+		{name: "undetectable comment", patch: domain.ReviewPatch{Comment: &shortComment}, wantComment: shortComment, wantGrade: 4, wantLanguage: "fr"},
+		{name: "grade only", patch: domain.ReviewPatch{Grade: &grade}, wantComment: "Stayed for a weekend", wantGrade: 5, wantLanguage: "fr", ratingChanged: true},
+		{name: "same grade", patch: domain.ReviewPatch{Grade: &sameGrade}, wantComment: "Stayed for a weekend", wantGrade: 4, wantLanguage: "fr"},
+		{name: "language", patch: domain.ReviewPatch{Language: &language}, wantComment: "Stayed for a weekend", wantGrade: 4, wantLanguage: "de"},
+		{name: "empty patch", patch: domain.ReviewPatch{}, wantComment: "Stayed for a weekend", wantGrade: 4, wantLanguage: "fr"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			publisher := &recordingPublisher{}
+			service := newReviewService(store, publisher, &fakeBookingClient{})
+			id := insertReviews(t, store, "host-1", domain.Host, 4)[0]
+			if _, err := store.Update(id, "Stayed for a weekend", 4, "fr", domain.Sentiment{}, nil); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+
+			review, err := service.Patch(id, test.patch, nil, span, nopLoki{})
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if review.Comment != test.wantComment || review.Grade != test.wantGrade || review.Language != test.wantLanguage {
+				t.Errorf("patched to %q, %v, %q, want %q, %v, %q", review.Comment, review.Grade, review.Language, test.wantComment, test.wantGrade, test.wantLanguage)
+			}
+			if _, published := publisher.Last("host-rating.changed"); published != test.ratingChanged {
+				t.Errorf("rating changed published = %v, want %v", published, test.ratingChanged)
+			}
+		})
+	}
+}
+
+func TestPatchWithStaleVersion(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	service := newReviewService(store, &recordingPublisher{}, &fakeBookingClient{})
+	id := insertReviews(t, store, "host-1", domain.Host, 4)[0]
+	grade := float32(2)
+
+	_, err := service.Patch(id, domain.ReviewPatch{Grade: &grade}, []int64{7}, span, nopLoki{})
+
+	if err != domain.ErrVersionMismatch {
+		t.Fatalf("err = %v, want %v", err, domain.ErrVersionMismatch)
+	}
+}
+
+func TestPatchReviewEndpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		status     int
+		wantFields []string
+	}{
+		{"grade only", `{"grade":2}`, http.StatusOK, nil},
+		{"reset language", `{"language":null}`, http.StatusOK, nil},
+		{"grade out of range", `{"grade":7}`, http.StatusBadRequest, []string{"grade"}},
+		{"zero grade", `{"grade":0}`, http.StatusBadRequest, []string{"grade"}},
+		{"empty comment", `{"comment":""}`, http.StatusBadRequest, []string{"comment"}},
+		{"remove grade", `{"grade":null}`, http.StatusBadRequest, []string{"grade"}},
+		{"change type", `{"reviewType":"guest"}`, http.StatusBadRequest, []string{"reviewType"}},
+		{"not an object", `[{"op":"replace","path":"/grade","value":2}]`, http.StatusBadRequest, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			id := insertReview(t, store)
+
+			w := serve(newRouter(store), http.MethodPatch, domain.GradeContextPath+"/"+id, test.body, domain.GuestRole)
+
+			if w.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, test.status, w.Body.String())
+			}
+			if test.status == http.StatusOK {
+				var review dto.ReviewDTO
+				if err := json.NewDecoder(w.Body).Decode(&review); err != nil {
+					t.Fatalf("body is not a review: %v", err)
+				}
+				if etag := w.Header().Get("ETag"); etag != `"2"` || review.Version != 2 {
+					t.Errorf("ETag = %s and version %d, want \"2\"", etag, review.Version)
+				}
+				return
+			}
+			response := decodeError(t, w)
+			if len(response.Details) != len(test.wantFields) {
+				t.Fatalf("fields = %+v, want %v", response.Details, test.wantFields)
+			}
+			for i, field := range test.wantFields {
+				if response.Details[i].Field != field {
+					t.Errorf("field %d = %q, want %q", i, response.Details[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestPatchReviewRequiresReviewerRole(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	id := insertReview(t, store)
+
+	w := serve(newRouter(store), http.MethodPatch, domain.GradeContextPath+"/"+id, `{"grade":2}`, domain.HostRole)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusForbidden, w.Body.String())
+	}
+}
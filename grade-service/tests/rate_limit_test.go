@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/api"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+var oneRequest = domain.RateLimit{Requests: 1, Period: time.Minute}
+
+func newLimitedRouter(t *testing.T, trustedHops int, limit domain.RateLimit) (*mux.Router, string) {
+	t.Helper()
+	store := persistence.NewReviewMemoryStore()
+	id := insertReview(t, store)
+	router := newVersionedRouter(store)
+	router.Use(api.NewRateLimiter(persistence.NewRateLimitMemoryStore(), trustedHops).
+		Limit(http.MethodGet, domain.GradeContextPath+"/{id}", limit).
+		Middleware)
+	return router, domain.GradeContextPath + "/" + id
+}
+
+func limitedGet(router http.Handler, path string, subject string, remoteAddr string, forwardedFor string) int {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	if subject != "" {
+		r.Header.Set(domain.JwtPayloadHeader, jwtPayload(subject))
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestRateLimitClientAddress(t *testing.T) {
+	tests := []struct {
+		name        string
+		trustedHops int
+		first       string
+		second      string
+		wantLimited bool
+	}{
+		{"forged first entry", 1, "198.51.100.1, 203.0.113.7", "198.51.100.2, 203.0.113.7", true},
+		{"different callers", 1, "203.0.113.7", "203.0.113.8", false},
+		{"two trusted hops", 2, "198.51.100.1, 203.0.113.7, 10.0.0.1", "198.51.100.2, 203.0.113.7, 10.0.0.2", true},
+		{"no trusted hops", 0, "203.0.113.7", "203.0.113.8", true},
+		{"fewer entries than hops", 2, "203.0.113.7", "203.0.113.8", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router, path := newLimitedRouter(t, test.trustedHops, oneRequest)
+
+			if status := limitedGet(router, path, "", "10.0.0.9:4000", test.first); status != http.StatusOK {
+				t.Fatalf("first request: status = %d, want %d", status, http.StatusOK)
+			}
+			status := limitedGet(router, path, "", "10.0.0.9:4001", test.second)
+
+			if limited := status == http.StatusTooManyRequests; limited != test.wantLimited {
+				t.Errorf("second request: status = %d, want limited %v", status, test.wantLimited)
+			}
+		})
+	}
+}
+
+func TestRateLimitRefundsSubjectWhenAddressIsLimited(t *testing.T) {
+	router, path := newLimitedRouter(t, 0, oneRequest)
+
+	if status := limitedGet(router, path, "user-a", "203.0.113.7:4000", ""); status != http.StatusOK {
+		t.Fatalf("status = %d, want %d", status, http.StatusOK)
+	}
+	if status := limitedGet(router, path, "user-b", "203.0.113.7:4001", ""); status != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want the address limited", status)
+	}
+
+	if status := limitedGet(router, path, "user-b", "203.0.113.8:4000", ""); status != http.StatusOK {
+		t.Errorf("status = %d, want user-b to keep the token of the rejected request", status)
+	}
+}
+
+func TestRateLimitWithoutRequests(t *testing.T) {
+	router, path := newLimitedRouter(t, 0, domain.RateLimit{Requests: 0, Period: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		if status := limitedGet(router, path, "", "203.0.113.7:4000", ""); status != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want the route unthrottled", i, status)
+		}
+	}
+}
+
+func TestRateLimitMemoryStore(t *testing.T) {
+	testRateLimitStore(t, persistence.NewRateLimitMemoryStore(), "memory")
+}
+
+// TestRateLimitMongoDBStore runs against the Mongo at GRADE_TEST_MONGO_URI.
+func TestRateLimitMongoDBStore(t *testing.T) {
+	uri := os.Getenv("GRADE_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("GRADE_TEST_MONGO_URI is not set")
+	}
+	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer client.Disconnect(context.TODO())
+
+	testRateLimitStore(t, persistence.NewRateLimitMongoDBStore(client), fmt.Sprintf("mongo-%d", time.Now().UnixNano()))
+}
+
+func testRateLimitStore(t *testing.T, store domain.RateLimitStore, prefix string) {
+	t.Helper()
+	limit := domain.RateLimit{Requests: 2, Period: time.Minute}
+	key := prefix + "|bucket"
+
+	for i := 0; i < limit.Requests; i++ {
+		if allowed, _, err := store.Take(key, limit); err != nil || !allowed {
+			t.Fatalf("take %d: allowed = %v, err = %v, want allowed", i, allowed, err)
+		}
+	}
+	allowed, retryAfter, err := store.Take(key, limit)
+	if err != nil || allowed {
+		t.Fatalf("allowed = %v, err = %v, want the bucket empty", allowed, err)
+	}
+	if retryAfter <= 0 || retryAfter > limit.Period/time.Duration(limit.Requests) {
+		t.Errorf("retry after %s, want at most %s", retryAfter, limit.Period/time.Duration(limit.Requests))
+	}
+
+	if err := store.Refund(key, limit); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if allowed, _, err := store.Take(key, limit); err != nil || !allowed {
+		t.Errorf("allowed = %v, err = %v, want the refunded token", allowed, err)
+	}
+
+	if _, _, err := store.Take(prefix+"|disabled", domain.RateLimit{Requests: 0, Period: time.Minute}); err == nil {
+		t.Errorf("err = nil, want a limit without requests rejected")
+	}
+}
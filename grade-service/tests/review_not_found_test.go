@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"errors"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"testing"
+)
+
+type failingReviewStore struct {
+	*persistence.ReviewMemoryStore
+}
+
+func (store failingReviewStore) GetAllBySubReviewed(subReviewed string, reviewType domain.ReviewType) ([]*domain.Review, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestListingStoreFailureWritesSingleError(t *testing.T) {
+	router := newRouter(failingReviewStore{persistence.NewReviewMemoryStore()})
+
+	w := serve(router, http.MethodGet, "/grade/host-1/host", "")
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if response := decodeError(t, w); response.Code != "internal_error" {
+		t.Errorf("code = %q, want internal_error", response.Code)
+	}
+}
+
+func TestUnknownReviewReturnsNotFound(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"update", http.MethodPut, "/grade/" + id, `{"comment":"Lovely place","grade":4,"reviewType":"host"}`},
+		{"delete", http.MethodDelete, "/grade/" + id, ""},
+		{"delete with type", http.MethodDelete, "/grade/" + id + "/host", ""},
+		{"vote", http.MethodPost, "/grade/" + id + "/helpful", ""},
+		{"report", http.MethodPost, "/grade/" + id + "/report", `{"reason":"spam"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := newRouter(persistence.NewReviewMemoryStore())
+
+			w := serve(router, test.method, test.path, test.body, domain.GuestRole)
+
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusNotFound, w.Body.String())
+			}
+			if response := decodeError(t, w); response.Code != domain.ErrReviewNotFound.Code {
+				t.Errorf("code = %q, want %q", response.Code, domain.ErrReviewNotFound.Code)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreReturnsReviewNotFound(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	id := primitive.NewObjectID()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"get", func() error { _, err := store.Get(id); return err }},
+		{"delete", func() error { return store.Delete(id) }},
+		{"update", func() error {
+			_, err := store.Update(id, "comment", 3, "en", domain.Sentiment{}, nil)
+			return err
+		}},
+		{"set vote counts", func() error { _, err := store.SetVoteCounts(id, 1, 0); return err }},
+		{"update status", func() error { _, err := store.UpdateStatus(id, domain.StatusPublished); return err }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.call(); !errors.Is(err, domain.ErrReviewNotFound) {
+				t.Errorf("err = %v, want %v", err, domain.ErrReviewNotFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,416 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mmmajder/zms-devops-grade-service/application"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/trace"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var span = trace.SpanFromContext(context.Background())
+
+func insertReviews(t *testing.T, store domain.ReviewStore, subReviewed string, reviewType domain.ReviewType, grades ...float32) []primitive.ObjectID {
+	t.Helper()
+	ids := make([]primitive.ObjectID, 0, len(grades))
+	for i, grade := range grades {
+		id, err := store.Insert(&domain.Review{
+			Comment:     "Stayed for a weekend",
+			Grade:       grade,
+			SubReviewer: "reviewer-" + string(rune('a'+i)),
+			SubReviewed: subReviewed,
+			Type:        reviewType,
+			Status:      domain.StatusPublished,
+		})
+		if err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func publishedRating(t *testing.T, publisher *recordingPublisher, topic string) dto.RatingChangedDTO {
+	t.Helper()
+	event, ok := publisher.Last(topic)
+	if !ok {
+		t.Fatalf("nothing published to %s, got %v", topic, publisher.Topics())
+	}
+	rating, ok := event.Data.(dto.RatingChangedDTO)
+	if !ok {
+		t.Fatalf("event data is %T, want dto.RatingChangedDTO", event.Data)
+	}
+	if event.Type != domain.RatingChangedEventType || event.Key != rating.Id {
+		t.Errorf("event type %q with key %q, want %q keyed by %q", event.Type, event.Key, domain.RatingChangedEventType, rating.Id)
+	}
+	return rating
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name       string
+		reviewType domain.ReviewType
+		booking    *fakeBookingClient
+		wantErr    error
+		wantKind   domain.ErrorKind
+		wantTopics []string
+	}{
+		{
+			name:       "host review with reservation",
+			reviewType: domain.Host,
+			booking:    &fakeBookingClient{HasReservation: true},
+			wantTopics: []string{"host-rating.changed", "host-review.created"},
+		},
+		{
+			name:       "accommodation review with reservation",
+			reviewType: domain.Accommodation,
+			booking:    &fakeBookingClient{HasReservation: true},
+			wantTopics: []string{"accommodation-rating.changed", "accommodation-review.created"},
+		},
+		{
+			name:       "guest review sends no notification",
+			reviewType: domain.Guest,
+			booking:    &fakeBookingClient{HasReservation: true},
+			wantTopics: []string{"guest-rating.changed"},
+		},
+		{
+			name:       "without reservation",
+			reviewType: domain.Host,
+			booking:    &fakeBookingClient{HasReservation: false},
+			wantErr:    domain.ErrReservationRequired,
+		},
+		{
+			name:       "booking unavailable",
+			reviewType: domain.Host,
+			booking:    &fakeBookingClient{Err: errors.New("connection refused")},
+			wantKind:   domain.KindUpstream,
+		},
+		{
+			name:       "unknown type",
+			reviewType: domain.ReviewType(42),
+			booking:    &fakeBookingClient{HasReservation: true},
+			wantKind:   domain.KindValidation,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			publisher := &recordingPublisher{}
+			service := newReviewService(store, publisher, test.booking)
+
+			review, err := service.Add(test.reviewType, "Clean and quiet", 4, "", "guest-1", "subject-1", "Ana Anic", "user-1", time.Time{}, span, nopLoki{})
+
+			stored, _ := store.GetAllBySubReviewed("subject-1", test.reviewType)
+			if test.wantErr != nil || test.wantKind != "" {
+				if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+					t.Fatalf("err = %v, want %v", err, test.wantErr)
+				}
+				if test.wantKind != "" && domain.AsError(err).Kind != test.wantKind {
+					t.Fatalf("err = %v, want kind %s", err, test.wantKind)
+				}
+				if len(stored) != 0 {
+					t.Errorf("stored %d reviews, want none", len(stored))
+				}
+				if topics := publisher.Topics(); len(topics) != 0 {
+					t.Errorf("published to %v, want nothing", topics)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(stored) != 1 || stored[0].Id != review.Id {
+				t.Fatalf("stored %v, want the added review %s", stored, review.Id.Hex())
+			}
+			if stored[0].Status != domain.StatusPublished {
+				t.Errorf("status = %q, want %q", stored[0].Status, domain.StatusPublished)
+			}
+			if topics := publisher.Topics(); !reflect.DeepEqual(topics, test.wantTopics) {
+				t.Errorf("published to %v, want %v", topics, test.wantTopics)
+			}
+		})
+	}
+}
+
+func TestWritesInspectInBackground(t *testing.T) {
+	tests := []struct {
+		name       string
+		autoHide   bool
+		wantStatus domain.ReviewStatus
+	}{
+		{"flagged", false, domain.StatusPublished},
+		{"hidden", true, domain.StatusPendingModeration},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			settings := fraudSettings()
+			settings.HideScore = 0
+			settings.AutoHide = test.autoHide
+			service := newReviewServiceWithDetector(store, &recordingPublisher{}, &fakeBookingClient{HasReservation: true}, application.NewFraudDetector(store, newSuspicionStore(), settings))
+
+			var ids []primitive.ObjectID
+			for i := 0; i < settings.BurstThreshold; i++ {
+				review, err := service.Add(domain.Host, "Clean and quiet", 5, "", fmt.Sprintf("guest-%d", i), "host-1", "Ana Anic", "user-1", time.Time{}, span, nopLoki{})
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				ids = append(ids, review.Id)
+			}
+			service.WaitForInspections()
+
+			for _, id := range ids {
+				review, err := store.Get(id)
+				if err != nil {
+					t.Fatalf("get: %v", err)
+				}
+				if !review.Flagged || review.Status != test.wantStatus {
+					t.Errorf("review by %s: flagged = %t, status = %q, want flagged and %q", review.SubReviewer, review.Flagged, review.Status, test.wantStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateInspectsInBackground(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	service := newReviewService(store, &recordingPublisher{}, &fakeBookingClient{HasReservation: true})
+	ids := seed(t, store, []seededReview{
+		{reviewer: "guest-1", grade: 4, at: time.Now().Add(-72 * time.Hour)},
+		{reviewer: "guest-2", grade: 5, at: time.Now().Add(-48 * time.Hour), comment: copiedComment},
+		{reviewer: "guest-3", grade: 5, at: time.Now().Add(-24 * time.Hour)},
+	})
+
+	if _, err := service.Update(ids[2], copiedComment, 5, nil, span, nopLoki{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	service.WaitForInspections()
+
+	for i, want := range []bool{false, true, true} {
+		if review, _ := store.Get(ids[i]); review.Flagged != want {
+			t.Errorf("review by %s: flagged = %t, want %t", review.SubReviewer, review.Flagged, want)
+		}
+	}
+}
+
+func TestGuestReviewChecksHostReservation(t *testing.T) {
+	booking := &fakeBookingClient{HasReservation: true}
+	service := newReviewService(persistence.NewReviewMemoryStore(), &recordingPublisher{}, booking)
+
+	if _, err := service.Add(domain.Guest, "Left the flat tidy", 5, "", "host-1", "guest-1", "Ana Anic", "user-1", time.Time{}, span, nopLoki{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if request := booking.HostRequest; request == nil || request.HostId != "host-1" || request.GuestId != "guest-1" {
+		t.Errorf("asked booking %v, want whether host-1 hosted guest-1", request)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name       string
+		grades     []float32
+		known      bool
+		grade      float32
+		wantErr    error
+		wantRating float32
+	}{
+		{name: "only review", grades: []float32{2}, known: true, grade: 5, wantRating: 5},
+		{name: "changes average", grades: []float32{2, 4}, known: true, grade: 5, wantRating: 4.5},
+		{name: "unknown review", grades: []float32{3}, known: false, grade: 5, wantErr: domain.ErrReviewNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			publisher := &recordingPublisher{}
+			service := newReviewService(store, publisher, &fakeBookingClient{})
+			ids := insertReviews(t, store, "host-1", domain.Host, test.grades...)
+			id := ids[0]
+			if !test.known {
+				id = primitive.NewObjectID()
+			}
+
+			_, err := service.Update(id, "Even better the second time", test.grade, nil, span, nopLoki{})
+
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("err = %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			updated, _ := store.Get(id)
+			if updated.Grade != test.grade || updated.Comment != "Even better the second time" {
+				t.Errorf("stored grade %v and comment %q", updated.Grade, updated.Comment)
+			}
+			if rating := publishedRating(t, publisher, "host-rating.changed"); rating.Rating != test.wantRating || rating.Id != "host-1" {
+				t.Errorf("published %+v, want rating %v for host-1", rating, test.wantRating)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name       string
+		grades     []float32
+		known      bool
+		wantErr    error
+		wantRating float32
+	}{
+		{name: "last review", grades: []float32{4}, known: true, wantRating: 0},
+		{name: "remaining reviews", grades: []float32{1, 4, 5}, known: true, wantRating: 4.5},
+		{name: "unknown review", grades: []float32{4}, known: false, wantErr: domain.ErrReviewNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			publisher := &recordingPublisher{}
+			service := newReviewService(store, publisher, &fakeBookingClient{})
+			ids := insertReviews(t, store, "host-1", domain.Host, test.grades...)
+			id := ids[0]
+			if !test.known {
+				id = primitive.NewObjectID()
+			}
+
+			err := service.Delete(id, span, nopLoki{})
+
+			remaining, _ := store.GetAllBySubReviewed("host-1", domain.Host)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("err = %v, want %v", err, test.wantErr)
+				}
+				if len(remaining) != len(test.grades) {
+					t.Errorf("%d reviews left, want %d", len(remaining), len(test.grades))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(remaining) != len(test.grades)-1 {
+				t.Errorf("%d reviews left, want %d", len(remaining), len(test.grades)-1)
+			}
+			if rating := publishedRating(t, publisher, "host-rating.changed"); rating.Rating != test.wantRating {
+				t.Errorf("published rating %v, want %v", rating.Rating, test.wantRating)
+			}
+		})
+	}
+}
+
+func TestAverageRating(t *testing.T) {
+	tests := []struct {
+		name   string
+		grades []float32
+		want   float32
+	}{
+		{name: "no reviews", grades: nil, want: 0},
+		{name: "single review", grades: []float32{3}, want: 3},
+		{name: "whole grades", grades: []float32{1, 2, 3, 4, 5}, want: 3},
+		{name: "fractional grades", grades: []float32{4.5, 3.5}, want: 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			service := newReviewService(store, &recordingPublisher{}, &fakeBookingClient{})
+			insertReviews(t, store, "host-1", domain.Host, test.grades...)
+			insertReviews(t, store, "host-2", domain.Host, 1, 1)
+
+			report, err := service.GetAllBySubReviewed("host-1", domain.Host, domain.ReviewFilter{}, domain.SortUnordered, "", span, nopLoki{})
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if report.AverageRating != test.want {
+				t.Errorf("average = %v, want %v", report.AverageRating, test.want)
+			}
+			if report.TotalReviews != len(test.grades) {
+				t.Errorf("total = %d, want %d", report.TotalReviews, len(test.grades))
+			}
+		})
+	}
+}
+
+func TestStarHistogram(t *testing.T) {
+	tests := []struct {
+		name   string
+		grades []float32
+		want   []int
+	}{
+		{name: "no reviews", grades: nil, want: []int{0, 0, 0, 0, 0}},
+		{name: "one of each", grades: []float32{1, 2, 3, 4, 5}, want: []int{1, 1, 1, 1, 1}},
+		{name: "fractions round up", grades: []float32{0.5, 1.5, 4.2, 4.8}, want: []int{1, 1, 0, 0, 2}},
+		{name: "only five stars", grades: []float32{5, 5, 5}, want: []int{0, 0, 0, 0, 3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			service := newReviewService(store, &recordingPublisher{}, &fakeBookingClient{})
+			insertReviews(t, store, "host-1", domain.Host, test.grades...)
+
+			report, err := service.GetAllBySubReviewed("host-1", domain.Host, domain.ReviewFilter{}, domain.SortUnordered, "", span, nopLoki{})
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(report.NumberOfStars) != 5 {
+				t.Fatalf("histogram has %d buckets, want 5", len(report.NumberOfStars))
+			}
+			for i, bucket := range report.NumberOfStars {
+				if bucket.Value != test.want[i] {
+					t.Errorf("%s stars = %d, want %d", bucket.Label, bucket.Value, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRatingVersions(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	publisher := &recordingPublisher{}
+	service := newReviewService(store, publisher, &fakeBookingClient{HasReservation: true})
+
+	steps := []struct {
+		subReviewed string
+		grade       float32
+		wantVersion int64
+	}{
+		{"accommodation-1", 5, 1},
+		{"accommodation-1", 3, 2},
+		{"accommodation-2", 4, 1},
+		{"accommodation-1", 1, 3},
+	}
+	for _, step := range steps {
+		if _, err := service.Add(domain.Accommodation, "Nice view", step.grade, "", "guest-1", step.subReviewed, "Ana Anic", "user-1", time.Time{}, span, nopLoki{}); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+		rating := publishedRating(t, publisher, "accommodation-rating.changed")
+		if rating.Id != step.subReviewed || rating.Version != step.wantVersion {
+			t.Errorf("published %+v, want version %d for %s", rating, step.wantVersion, step.subReviewed)
+		}
+	}
+
+	report, err := service.GetAllBySubReviewed("accommodation-1", domain.Accommodation, domain.ReviewFilter{}, domain.SortUnordered, "", span, nopLoki{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.RatingVersion != 3 {
+		t.Errorf("report version = %d, want 3", report.RatingVersion)
+	}
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/mmmajder/zms-devops-grade-service/application"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"testing"
+)
+
+func TestRegisterRejectsDuplicates(t *testing.T) {
+	host, err := defaultReviewTypes().Get(domain.Host)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	renamed := host
+	renamed.Name = "landlord"
+	retyped := host
+	retyped.Type = domain.ReviewType(7)
+
+	registry := application.NewReviewTypeRegistry()
+	if err := registry.Register(host); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for name, definition := range map[string]application.ReviewTypeDefinition{"same type": renamed, "same name": retyped} {
+		if err := registry.Register(definition); err == nil {
+			t.Errorf("%s: registered twice", name)
+		}
+	}
+}
+
+func TestDeleteRefreshesStoredType(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	publisher := &recordingPublisher{}
+	service := newReviewService(store, publisher, &fakeBookingClient{})
+	id := insertReviews(t, store, "apartment-1", domain.Accommodation, 4)[0]
+
+	if err := service.Delete(id, span, nopLoki{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if topics := publisher.Topics(); len(topics) != 1 || topics[0] != "accommodation-rating.changed" {
+		t.Errorf("published %v, want only accommodation-rating.changed", topics)
+	}
+}
+
+func TestReviewTypeInRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		prefix         string
+		path           string
+		body           string
+		status         int
+		wantDeprecated bool
+	}{
+		{"delete", http.MethodDelete, domain.GradeContextPath, "", "", http.StatusOK, true},
+		{"delete with stored type", http.MethodDelete, domain.GradeContextPath, "/host", "", http.StatusOK, true},
+		{"delete with other type", http.MethodDelete, domain.GradeContextPath, "/guest", "", http.StatusConflict, true},
+		{"update", http.MethodPut, domain.GradeContextPath, "", `{"comment":"Lovely place","grade":4}`, http.StatusOK, true},
+		{"update with stored type", http.MethodPut, domain.GradeContextPath, "", `{"comment":"Lovely place","grade":4,"reviewType":"host"}`, http.StatusOK, true},
+		{"update with other type", http.MethodPut, domain.GradeContextPath, "", `{"comment":"Lovely place","grade":4,"reviewType":"accommodation"}`, http.StatusConflict, true},
+		{"v2 delete", http.MethodDelete, domain.GradeV2ContextPath, "", "", http.StatusOK, false},
+		{"v2 delete with type", http.MethodDelete, domain.GradeV2ContextPath, "/host", "", http.StatusNotFound, false},
+		{"v2 update", http.MethodPut, domain.GradeV2ContextPath, "", `{"comment":"Lovely place","grade":4}`, http.StatusOK, false},
+		{"v2 update with type", http.MethodPut, domain.GradeV2ContextPath, "", `{"comment":"Lovely place","grade":4,"reviewType":"host"}`, http.StatusBadRequest, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			id := insertReview(t, store)
+
+			w := serve(newRouter(store), test.method, test.prefix+"/"+id+test.path, test.body, domain.GuestRole)
+
+			if w.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, test.status, w.Body.String())
+			}
+			if deprecated := w.Header().Get("Deprecation") != ""; deprecated != test.wantDeprecated {
+				t.Errorf("deprecated = %v, want %v", deprecated, test.wantDeprecated)
+			}
+			switch test.status {
+			case http.StatusConflict:
+				if response := decodeError(t, w); response.Code != domain.ErrReviewTypeMismatch.Code {
+					t.Errorf("code = %q, want %q", response.Code, domain.ErrReviewTypeMismatch.Code)
+				}
+			case http.StatusBadRequest:
+				if response := decodeError(t, w); len(response.Details) != 1 || response.Details[0].Field != "reviewType" {
+					t.Errorf("details = %v, want reviewType rejected", response.Details)
+				}
+			}
+			if test.status >= http.StatusBadRequest {
+				reviewId, _ := primitive.ObjectIDFromHex(id)
+				if review, err := store.Get(reviewId); err != nil || review.Version != 1 {
+					t.Errorf("rejected request changed the review")
+				}
+			}
+		})
+	}
+}
+
+func TestGetReviewTypesListsCriteria(t *testing.T) {
+	for _, prefix := range []string{domain.GradeContextPath, domain.GradeV2ContextPath} {
+		t.Run(prefix, func(t *testing.T) {
+			w := serve(newRouter(persistence.NewReviewMemoryStore()), http.MethodGet, prefix+"/types", "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+			var reviewTypes []dto.ReviewTypeDTO
+			if err := json.Unmarshal(w.Body.Bytes(), &reviewTypes); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(reviewTypes) != 3 {
+				t.Fatalf("got %d review types, want 3", len(reviewTypes))
+			}
+			for _, reviewType := range reviewTypes {
+				if len(reviewType.Criteria) == 0 {
+					t.Errorf("review type %s has no criteria", reviewType.Name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"github.com/mmmajder/zms-devops-grade-service/application/text"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"reflect"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"words", "clean host", []string{"Clean", "host"}},
+		{"phrase", `"friendly host"`, []string{"friendly host"}},
+		{"negated word", "clean -host", []string{"Clean"}},
+		{"negated phrase", `room -"friendly host"`, []string{"room"}},
+		{"unclosed phrase", `"clean room`, []string{"Clean room"}},
+		{"only negations", "-clean -", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var matches []string
+			for _, fragment := range text.Highlight("Clean room and a friendly host", test.query, 100) {
+				if fragment.Match {
+					matches = append(matches, fragment.Text)
+				}
+			}
+			if !reflect.DeepEqual(matches, test.want) {
+				t.Errorf("matches = %q, want %q", matches, test.want)
+			}
+		})
+	}
+}
+
+func TestSearchCountsBeforeLimit(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	service := newReviewService(store, &recordingPublisher{}, &fakeBookingClient{})
+	insertReviews(t, store, "host-1", domain.Host, 5, 4, 3)
+
+	response, err := service.Search("host-1", domain.Host, "weekend", domain.ReviewFilter{}, 2, span, nopLoki{})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.TotalResults != 3 || len(response.Results) != 2 {
+		t.Errorf("got %d results of %d, want 2 of 3", len(response.Results), response.TotalResults)
+	}
+}
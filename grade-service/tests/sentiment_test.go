@@ -0,0 +1,33 @@
+package tests
+
+import (
+	"github.com/mmmajder/zms-devops-grade-service/application/text"
+	"testing"
+)
+
+func TestSentimentWithoutDiacritics(t *testing.T) {
+	tests := []struct {
+		name         string
+		withoutMarks string
+		withMarks    string
+		wantPositive bool
+	}{
+		{"praise", "Odlican smestaj, cisto i uredno", "Odličan smeštaj, čisto i uredno", true},
+		{"complaint", "Prljavo i lose, uzas", "Prljavo i loše, užas", false},
+		{"negated intensifier", "Nije bas cisto", "Nije baš čisto", false},
+		{"cyrillic", "Одличан смештај", "Odličan smeštaj", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			score := text.Sentiment(test.withoutMarks)
+
+			if score == 0 || (score > 0) != test.wantPositive {
+				t.Errorf("score = %v, want positive %v", score, test.wantPositive)
+			}
+			if marked := text.Sentiment(test.withMarks); marked != score {
+				t.Errorf("score = %v, want %v as with diacritics", score, marked)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/api"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/dto"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var v1Deprecation = api.Deprecation{
+	Date:      time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+	Sunset:    time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC),
+	Successor: domain.GradeV2ContextPath,
+}
+
+func newVersionedRouter(store domain.ReviewStore) *mux.Router {
+	reviewService := newReviewService(store, &recordingPublisher{}, &fakeBookingClient{HasReservation: true})
+	router := mux.NewRouter()
+	api.MountVersions(router, v1Deprecation, api.NewReviewHandler(reviewService, sdktrace.NewTracerProvider(), nopLoki{}))
+	return router
+}
+
+func TestDeprecationHeaders(t *testing.T) {
+	tests := []struct {
+		prefix         string
+		wantDeprecated bool
+	}{
+		{domain.GradeContextPath, true},
+		{domain.GradeV1ContextPath, true},
+		{domain.GradeV2ContextPath, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.prefix, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			id := insertReview(t, store)
+
+			w := serve(newVersionedRouter(store), http.MethodGet, test.prefix+"/"+id, "")
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+			if !test.wantDeprecated {
+				if header := w.Header().Get("Deprecation"); header != "" {
+					t.Errorf("Deprecation = %q, want none", header)
+				}
+				return
+			}
+			if header := w.Header().Get("Deprecation"); header != "@1792368000" {
+				t.Errorf("Deprecation = %q, want @1792368000", header)
+			}
+			if header := w.Header().Get("Sunset"); header != "Fri, 30 Apr 2027 00:00:00 GMT" {
+				t.Errorf("Sunset = %q, want Fri, 30 Apr 2027 00:00:00 GMT", header)
+			}
+			if header := w.Header().Get("Link"); header != `</grade/v2>; rel="successor-version"` {
+				t.Errorf("Link = %q, want the v2 successor", header)
+			}
+		})
+	}
+}
+
+func TestReviewTypeByVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"v1 by value", domain.GradeV1ContextPath + "/host-1/0", http.StatusOK},
+		{"v1 by name", domain.GradeV1ContextPath + "/host-1/host", http.StatusOK},
+		{"unversioned by value", domain.GradeContextPath + "/host-1/0", http.StatusOK},
+		{"v2 by value", domain.GradeV2ContextPath + "/host-1/0", http.StatusBadRequest},
+		{"v2 by name", domain.GradeV2ContextPath + "/host-1/host", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := persistence.NewReviewMemoryStore()
+			insertReviews(t, store, "host-1", domain.Host, 4)
+
+			w := serve(newVersionedRouter(store), http.MethodGet, test.path, "")
+
+			if w.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, test.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestReviewPage(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	insertReviews(t, store, "host-1", domain.Host, 5, 4, 3, 2, 1)
+	router := newVersionedRouter(store)
+
+	w := serve(router, http.MethodGet, domain.GradeV2ContextPath+"/host-1/host?page=2&pageSize=2", "")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var page dto.ReviewReportPageDTO
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if page.Page != 2 || page.PageSize != 2 || page.TotalPages != 3 || page.MatchingReviews != 5 {
+		t.Errorf("page %d of %d with size %d and %d matching, want page 2 of 3 with size 2 and 5 matching", page.Page, page.TotalPages, page.PageSize, page.MatchingReviews)
+	}
+	if len(page.Reviews) != 2 || page.TotalReviews != 5 {
+		t.Errorf("got %d reviews of %d, want 2 of 5", len(page.Reviews), page.TotalReviews)
+	}
+
+	w = serve(router, http.MethodGet, domain.GradeV2ContextPath+"/host-1/host?pageSize=500", "")
+	if w.Code != http.StatusBadRequest || decodeError(t, w).Code != "invalid_page" {
+		t.Errorf("status = %d, want invalid_page", w.Code)
+	}
+
+	w = serve(router, http.MethodGet, domain.GradeV1ContextPath+"/host-1/host", "")
+	var report struct {
+		Reviews    []json.RawMessage `json:"reviews"`
+		TotalPages *int              `json:"totalPages"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.TotalPages != nil || len(report.Reviews) != 5 {
+		t.Errorf("v1 report has %d reviews and paginated %v, want all 5 unpaginated", len(report.Reviews), report.TotalPages != nil)
+	}
+}
+
+func TestV2DropsDeleteWithType(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	id := insertReview(t, store)
+
+	w := serve(newVersionedRouter(store), http.MethodDelete, domain.GradeV2ContextPath+"/"+id+"/host", "", domain.GuestRole)
+
+	if w.Code != http.StatusNotFound && w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want the route to be gone", w.Code)
+	}
+	reviewId, _ := primitive.ObjectIDFromHex(id)
+	if _, err := store.Get(reviewId); err != nil {
+		t.Errorf("review was deleted: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"errors"
+	"github.com/mmmajder/zms-devops-grade-service/domain"
+	"github.com/mmmajder/zms-devops-grade-service/infrastructure/persistence"
+	"testing"
+)
+
+func TestVote(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	service := newReviewService(store, &recordingPublisher{}, &fakeBookingClient{})
+	id := insertReviews(t, store, "host-1", domain.Host, 4)[0]
+
+	steps := []struct {
+		name           string
+		voter          string
+		helpful        bool
+		wantHelpful    int
+		wantNotHelpful int
+	}{
+		{"first vote", "voter-1", true, 1, 0},
+		{"same vote again", "voter-1", true, 1, 0},
+		{"changed vote", "voter-1", false, 0, 1},
+		{"second voter", "voter-2", true, 1, 1},
+	}
+
+	for _, step := range steps {
+		review, err := service.Vote(id, step.voter, step.helpful, span, nopLoki{})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		if review.HelpfulCount != step.wantHelpful || review.NotHelpfulCount != step.wantNotHelpful {
+			t.Errorf("%s: counts = %d/%d, want %d/%d", step.name, review.HelpfulCount, review.NotHelpfulCount, step.wantHelpful, step.wantNotHelpful)
+		}
+	}
+}
+
+func TestVoteOnOwnReview(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	service := newReviewService(store, &recordingPublisher{}, &fakeBookingClient{})
+	id := insertReviews(t, store, "host-1", domain.Host, 4)[0]
+
+	if _, err := service.Vote(id, "reviewer-a", true, span, nopLoki{}); !errors.Is(err, domain.ErrOwnReviewVote) {
+		t.Errorf("err = %v, want %v", err, domain.ErrOwnReviewVote)
+	}
+}
+
+func TestVoteRepairsLostCounts(t *testing.T) {
+	store := persistence.NewReviewMemoryStore()
+	service := newReviewService(store, &recordingPublisher{}, &fakeBookingClient{})
+	id := insertReviews(t, store, "host-1", domain.Host, 4)[0]
+	if _, err := service.Vote(id, "voter-1", true, span, nopLoki{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// As if the process died between storing a vote and counting it.
+	if _, err := store.SetVoteCounts(id, 0, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	review, err := service.Vote(id, "voter-1", true, span, nopLoki{})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if review.HelpfulCount != 1 || review.NotHelpfulCount != 0 {
+		t.Errorf("counts = %d/%d, want 1/0", review.HelpfulCount, review.NotHelpfulCount)
+	}
+}